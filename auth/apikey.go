@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/dexlabsio/garlic/crypto"
+	"github.com/dexlabsio/garlic/errors"
+)
+
+// APIKeyStore looks up API keys by their SHA-256 hash. It returns a nil
+// entry when no key matches the hash.
+type APIKeyStore interface {
+	LookupAPIKey(ctx context.Context, hash string) (*APIKeyEntry, error)
+}
+
+// StaticAPIKeyStore serves the keys declared in the configuration.
+type StaticAPIKeyStore map[string]*APIKeyEntry
+
+func NewStaticAPIKeyStore(entries []*APIKeyEntry) StaticAPIKeyStore {
+	store := StaticAPIKeyStore{}
+	for _, entry := range entries {
+		store[entry.Hash] = entry
+	}
+	return store
+}
+
+func (s StaticAPIKeyStore) LookupAPIKey(ctx context.Context, hash string) (*APIKeyEntry, error) {
+	return s[hash], nil
+}
+
+// APIKeyBackend authenticates requests carrying an API key header. Keys are
+// hashed before being looked up, so the store never handles them in plain
+// text.
+type APIKeyBackend struct {
+	header string
+	store  APIKeyStore
+}
+
+func NewAPIKeyBackend(config *APIKeyConfig, store APIKeyStore) *APIKeyBackend {
+	if store == nil {
+		store = NewStaticAPIKeyStore(config.Keys)
+	}
+
+	return &APIKeyBackend{
+		header: config.Header,
+		store:  store,
+	}
+}
+
+// Authenticate implements AuthBackend. Requests without the API key header
+// are left to the next backend.
+func (b *APIKeyBackend) Authenticate(r *http.Request) (*Principal, error) {
+	key := r.Header.Get(b.header)
+	if key == "" {
+		return nil, nil
+	}
+
+	entry, err := b.store.LookupAPIKey(r.Context(), crypto.HashSHA256(key))
+	if err != nil {
+		return nil, errors.Propagate(err, "failed to look up API key")
+	}
+
+	if entry == nil {
+		return nil, errors.New(
+			KindAuthError,
+			"invalid API key",
+			errors.Hint("Please verify the key sent in the %s header.", b.header),
+		)
+	}
+
+	if entry.Disabled {
+		return nil, errors.New(
+			KindForbiddenError,
+			"API key is disabled",
+			errors.Context(errors.Field("subject", entry.Subject)),
+			errors.Hint("Please contact an administrator to enable the key."),
+		)
+	}
+
+	return &Principal{
+		Subject:        entry.Subject,
+		OrganizationId: entry.OrganizationId,
+		Superuser:      entry.Superuser,
+		Roles:          entry.Roles,
+		Scopes:         entry.Scopes,
+		Method:         MethodAPIKey,
+	}, nil
+}
@@ -0,0 +1,221 @@
+//go:build unit
+// +build unit
+
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	stderrors "errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dexlabsio/garlic/crypto"
+	"github.com/dexlabsio/garlic/errors"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func serve(t *testing.T, mw func(http.Handler) http.Handler, header, value string) (*httptest.ResponseRecorder, *Principal) {
+	var principal *Principal
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, _ = GetPrincipalFromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if header != "" {
+		req.Header.Set(header, value)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec, principal
+}
+
+func TestJWTHS256(t *testing.T) {
+	config := JWTConfigDefaults()
+	config.Secret = "secret"
+	config.Issuer = "garlic"
+
+	backend, err := NewJWTBackend(config)
+	assert.NoError(t, err)
+
+	token, err := backend.NewJWT("user", "org", true)
+	assert.NoError(t, err)
+
+	rec, principal := serve(t, Authenticate(backend), "Authorization", "Bearer "+token)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, &Principal{Subject: "user", OrganizationId: "org", Superuser: true, Method: MethodJWT}, principal)
+
+	rec, _ = serve(t, Authenticate(backend), "Authorization", "Bearer "+token+"x")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec, _ = serve(t, Authenticate(backend), "", "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec, principal = serve(t, Optional(backend), "", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Nil(t, principal)
+
+	other := JWTConfigDefaults()
+	other.Secret = "secret"
+	other.Issuer = "someone-else"
+	otherBackend, err := NewJWTBackend(other)
+	assert.NoError(t, err)
+
+	rec, _ = serve(t, Authenticate(otherBackend), "Authorization", "Bearer "+token)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestJWTHS256RequiresPrivateSecret(t *testing.T) {
+	backends, err := Backends(Defaults())
+	assert.NoError(t, err)
+	assert.Empty(t, backends)
+
+	config := Defaults()
+	config.JWT = JWTConfigDefaults()
+	_, err = Backends(config)
+	assert.Error(t, err)
+
+	config.JWT.Secret = PlaceholderSecret
+	_, err = NewJWTBackend(config.JWT)
+	assert.Error(t, err)
+}
+
+func TestJWTRS256WithJWKS(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	dir := t.TempDir()
+	jwks, _ := json.Marshal(JWKS{Keys: []JWK{{
+		Kty: "RSA",
+		Kid: "k1",
+		Use: "sig",
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}})
+	jwksFile := filepath.Join(dir, "jwks.json")
+	assert.NoError(t, os.WriteFile(jwksFile, jwks, 0o600))
+
+	signer := &JWTBackend{config: &JWTConfig{Algorithm: AlgorithmRS256, KeyId: "k1"}}
+	signer.method = jwt.SigningMethodRS256
+	signer.privateKey = key
+
+	token, err := signer.Sign(&Claims{Roles: []string{"admin"}})
+	assert.NoError(t, err)
+
+	config := JWTConfigDefaults()
+	config.Algorithm = AlgorithmRS256
+	config.JWKSFile = jwksFile
+
+	backend, err := NewJWTBackend(config)
+	assert.NoError(t, err)
+
+	rec, principal := serve(t, Authenticate(backend), "Authorization", "Bearer "+token)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, []string{"admin"}, principal.Roles)
+
+	// HS256 tokens must not be accepted by an RS256 backend.
+	hsConfig := JWTConfigDefaults()
+	hsConfig.Secret = "secret"
+	hsBackend, _ := NewJWTBackend(hsConfig)
+	hsToken, _ := hsBackend.NewJWT("user", "org", false)
+
+	rec, _ = serve(t, Authenticate(backend), "Authorization", "Bearer "+hsToken)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestAPIKey(t *testing.T) {
+	config := APIKeyConfigDefaults()
+	config.Keys = []*APIKeyEntry{
+		{Hash: crypto.HashSHA256("valid"), Subject: "service", Scopes: []string{"read"}},
+		{Hash: crypto.HashSHA256("disabled"), Subject: "old-service", Disabled: true},
+	}
+	backend := NewAPIKeyBackend(config, nil)
+
+	rec, principal := serve(t, Authenticate(backend), "X-API-KEY", "valid")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, &Principal{Subject: "service", Scopes: []string{"read"}, Method: MethodAPIKey}, principal)
+
+	rec, _ = serve(t, Authenticate(backend), "X-API-KEY", "invalid")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec, _ = serve(t, Authenticate(backend), "X-API-KEY", "disabled")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestBackendsOrder(t *testing.T) {
+	failing := NewInvalidAuthBackendMock(errors.New(KindForbiddenError, "denied"))
+	anonymous := NewAuthBackendMock(nil)
+	valid := NewAuthBackendMock(&Principal{Subject: "user"})
+
+	rec, principal := serve(t, Authenticate(anonymous, valid, failing), "", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "user", principal.Subject)
+
+	rec, _ = serve(t, Authenticate(anonymous, failing, valid), "", "")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestParseKeySetSkipsOtherKeys(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	jwks, _ := json.Marshal(JWKS{Keys: []JWK{
+		{Kty: "EC", Kid: "ec", Use: "sig"},
+		{Kty: "RSA", Kid: "enc", Use: "enc", N: "!", E: "!"},
+		{
+			Kty: "RSA",
+			Kid: "k1",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		},
+	}})
+
+	keys, err := parseKeySet(jwks)
+	assert.NoError(t, err)
+	assert.Len(t, keys, 1)
+	assert.True(t, key.PublicKey.Equal(keys["k1"]))
+}
+
+type countingTransport struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (c *countingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.calls++
+	return nil, stderrors.New("connection refused")
+}
+
+func TestRemoteKeySetThrottlesFailedRefreshes(t *testing.T) {
+	transport := &countingTransport{}
+	set := NewRemoteKeySet("http://issuer.invalid/jwks.json", time.Hour)
+	set.client = &http.Client{Transport: transport}
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := set.Key("unknown")
+			assert.Error(t, err)
+		}()
+	}
+	wg.Wait()
+
+	_, err := set.Key("unknown")
+	assert.Error(t, err)
+	assert.Equal(t, 1, transport.calls)
+}
@@ -0,0 +1,31 @@
+package auth
+
+import "net/http"
+
+// AuthBackend authenticates requests using one kind of credentials. When
+// the request doesn't carry the credentials handled by the backend, it
+// returns a nil principal and a nil error so the next backend can be tried.
+// When the credentials are present but invalid it returns an error.
+type AuthBackend interface {
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+// Backends builds the backends enabled in the configuration: JWT first, then
+// the static API keys.
+func Backends(config *Config) ([]AuthBackend, error) {
+	backends := []AuthBackend{}
+
+	if config.JWT != nil {
+		jwtBackend, err := NewJWTBackend(config.JWT)
+		if err != nil {
+			return nil, err
+		}
+		backends = append(backends, jwtBackend)
+	}
+
+	if config.APIKey != nil && len(config.APIKey.Keys) > 0 {
+		backends = append(backends, NewAPIKeyBackend(config.APIKey, nil))
+	}
+
+	return backends, nil
+}
@@ -0,0 +1,74 @@
+package auth
+
+import "time"
+
+type Config struct {
+	JWT    *JWTConfig    `mapstructure:"jwt" yaml:"jwt"`
+	APIKey *APIKeyConfig `mapstructure:"apikey" yaml:"apikey"`
+
+	// Roles maps each role to the permissions it grants, used by Policy.
+	Roles map[string][]string `mapstructure:"roles" yaml:"roles"`
+}
+
+// PlaceholderSecret is the HS256 secret of old sample configurations. It's
+// public, so backends refuse it like an empty secret.
+const PlaceholderSecret = "INSECURE_CHANGEME"
+
+// JWTConfig describes how tokens are signed and verified. HS256 uses the
+// shared Secret for both. RS256 signs with PrivateKeyFile and verifies with
+// the keys published in JWKSFile or JWKSURL, falling back to the public key
+// of PrivateKeyFile when no JWKS is configured.
+type JWTConfig struct {
+	Algorithm           string        `mapstructure:"algorithm" yaml:"algorithm"`
+	Secret              string        `mapstructure:"secret" yaml:"secret"`
+	PrivateKeyFile      string        `mapstructure:"private_key_file" yaml:"private_key_file"`
+	KeyId               string        `mapstructure:"key_id" yaml:"key_id"`
+	JWKSFile            string        `mapstructure:"jwks_file" yaml:"jwks_file"`
+	JWKSURL             string        `mapstructure:"jwks_url" yaml:"jwks_url"`
+	JWKSRefreshInterval time.Duration `mapstructure:"jwks_refresh_interval" yaml:"jwks_refresh_interval"`
+	Issuer              string        `mapstructure:"issuer" yaml:"issuer"`
+	Audience            string        `mapstructure:"audience" yaml:"audience"`
+	Expiration          time.Duration `mapstructure:"expiration" yaml:"expiration"`
+}
+
+// APIKeyConfig describes the static API keys accepted by the service. Keys
+// are never configured in plain text, only their SHA-256 hashes.
+type APIKeyConfig struct {
+	Header string         `mapstructure:"header" yaml:"header"`
+	Keys   []*APIKeyEntry `mapstructure:"keys" yaml:"keys"`
+}
+
+type APIKeyEntry struct {
+	Hash           string   `mapstructure:"hash" yaml:"hash"`
+	Subject        string   `mapstructure:"subject" yaml:"subject"`
+	OrganizationId string   `mapstructure:"organization_id" yaml:"organization_id"`
+	Superuser      bool     `mapstructure:"superuser" yaml:"superuser"`
+	Roles          []string `mapstructure:"roles" yaml:"roles"`
+	Scopes         []string `mapstructure:"scopes" yaml:"scopes"`
+	Disabled       bool     `mapstructure:"disabled" yaml:"disabled"`
+}
+
+// Defaults returns a config without JWT, which needs a secret or keys of
+// the service, so it's enabled by setting JWT, starting from
+// JWTConfigDefaults.
+func Defaults() *Config {
+	return &Config{
+		APIKey: APIKeyConfigDefaults(),
+		Roles:  map[string][]string{},
+	}
+}
+
+func JWTConfigDefaults() *JWTConfig {
+	return &JWTConfig{
+		Algorithm:           AlgorithmHS256,
+		JWKSRefreshInterval: 15 * time.Minute,
+		Expiration:          24 * time.Hour,
+	}
+}
+
+func APIKeyConfigDefaults() *APIKeyConfig {
+	return &APIKeyConfig{
+		Header: "X-API-KEY",
+		Keys:   []*APIKeyEntry{},
+	}
+}
@@ -0,0 +1,9 @@
+package auth
+
+import "github.com/dexlabsio/garlic/errors"
+
+var (
+	KindAuthError      = errors.Get("AuthError")
+	KindForbiddenError = errors.Get("ForbiddenError")
+	KindContextError   = errors.Get("ContextError")
+)
@@ -0,0 +1,253 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"github.com/dexlabsio/garlic/errors"
+)
+
+// minRefreshInterval limits how often an unknown key id triggers a new
+// download of a remote JWKS, so forged tokens can't flood the issuer.
+const minRefreshInterval = 30 * time.Second
+
+// JWKS is a JSON Web Key Set as published by identity providers.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWK is a single JSON Web Key. Only RSA keys are supported.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// PublicKey decodes the RSA public key.
+func (k *JWK) PublicKey() (*rsa.PublicKey, error) {
+	if k.Kty != "RSA" {
+		return nil, errors.New(
+			errors.KindSystemError,
+			"unsupported key type",
+			errors.Context(errors.Field("kid", k.Kid), errors.Field("kty", k.Kty)),
+		)
+	}
+
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, errors.Propagate(err, "failed to decode key modulus", errors.Context(errors.Field("kid", k.Kid)))
+	}
+
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, errors.Propagate(err, "failed to decode key exponent", errors.Context(errors.Field("kid", k.Kid)))
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
+
+// KeySet holds the public keys used to verify RS256 tokens, indexed by
+// their key id. Remote sets are refreshed once RefreshInterval elapses and
+// when a token references an unknown key id.
+type KeySet struct {
+	url             string
+	refreshInterval time.Duration
+	client          *http.Client
+	group           singleflight.Group
+
+	mu          sync.RWMutex
+	keys        map[string]*rsa.PublicKey
+	refreshedAt time.Time
+}
+
+// StaticKeySet creates a key set that never changes.
+func StaticKeySet(keys map[string]*rsa.PublicKey) *KeySet {
+	return &KeySet{keys: keys}
+}
+
+// LoadKeySet reads a JWKS document from a file.
+func LoadKeySet(path string) (*KeySet, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Propagate(err, "failed to read JWKS file", errors.Context(errors.Field("path", path)))
+	}
+
+	keys, err := parseKeySet(content)
+	if err != nil {
+		return nil, errors.Propagate(err, "failed to load JWKS file", errors.Context(errors.Field("path", path)))
+	}
+
+	return StaticKeySet(keys), nil
+}
+
+// NewRemoteKeySet creates a key set downloaded from the given URL on first
+// use and refreshed periodically.
+func NewRemoteKeySet(url string, refreshInterval time.Duration) *KeySet {
+	return &KeySet{
+		url:             url,
+		refreshInterval: refreshInterval,
+		client:          &http.Client{Timeout: 10 * time.Second},
+		keys:            map[string]*rsa.PublicKey{},
+	}
+}
+
+// Key returns the public key with the given id. An empty id is accepted
+// when the set holds a single key.
+func (s *KeySet) Key(kid string) (*rsa.PublicKey, error) {
+	key, found, stale := s.lookup(kid)
+	if s.url != "" && (stale || !found) {
+		if err := s.refresh(context.Background(), !found); err != nil {
+			// Stale keys are still good enough while the issuer is unreachable.
+			if !found {
+				return nil, err
+			}
+		}
+		key, found, _ = s.lookup(kid)
+	}
+
+	if !found {
+		return nil, errors.New(
+			KindAuthError,
+			"unknown signing key",
+			errors.Context(errors.Field("kid", kid)),
+		)
+	}
+
+	return key, nil
+}
+
+func (s *KeySet) lookup(kid string) (key *rsa.PublicKey, found bool, stale bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stale = s.refreshInterval > 0 && time.Since(s.refreshedAt) > s.refreshInterval
+
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true, stale
+		}
+	}
+
+	key, found = s.keys[kid]
+	return key, found, stale
+}
+
+// refresh downloads the remote set. Refreshes caused by unknown key ids are
+// throttled by minRefreshInterval. Concurrent refreshes share a single
+// download, which runs without holding the lock so verifications of known
+// keys aren't blocked by a slow issuer.
+func (s *KeySet) refresh(ctx context.Context, unknownKey bool) error {
+	_, err, _ := s.group.Do("refresh", func() (any, error) {
+		if !s.startRefresh(unknownKey) {
+			return nil, nil
+		}
+
+		keys, err := s.download(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		s.mu.Lock()
+		s.keys = keys
+		s.mu.Unlock()
+
+		return nil, nil
+	})
+
+	return err
+}
+
+// startRefresh reports whether the set must be downloaded again, recording
+// the attempt when it does, even before it's known to succeed, so an
+// unreachable issuer isn't hammered on every request.
+func (s *KeySet) startRefresh(unknownKey bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elapsed := time.Since(s.refreshedAt)
+	if unknownKey && elapsed < minRefreshInterval {
+		return false
+	}
+	if !unknownKey && s.refreshInterval > 0 && elapsed <= s.refreshInterval {
+		// Another request refreshed the set in the meantime.
+		return false
+	}
+
+	s.refreshedAt = time.Now()
+	return true
+}
+
+func (s *KeySet) download(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	ectx := errors.Context(errors.Field("url", s.url))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, errors.Propagate(err, "failed to create JWKS request", ectx)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, errors.Propagate(err, "failed to download JWKS", ectx)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(
+			errors.KindSystemError,
+			"unexpected JWKS response",
+			errors.Context(errors.Field("url", s.url), errors.Field("status_code", resp.StatusCode)),
+		)
+	}
+
+	var content json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&content); err != nil {
+		return nil, errors.Propagate(err, "failed to decode JWKS", ectx)
+	}
+
+	keys, err := parseKeySet(content)
+	if err != nil {
+		return nil, errors.Propagate(err, "failed to load JWKS", ectx)
+	}
+
+	return keys, nil
+}
+
+// parseKeySet decodes the RSA signing keys of the set. Other keys, such as
+// EC or encryption keys published alongside them, are skipped.
+func parseKeySet(content []byte) (map[string]*rsa.PublicKey, error) {
+	var set JWKS
+	if err := json.Unmarshal(content, &set); err != nil {
+		return nil, errors.Propagate(err, "failed to decode JWKS")
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+	for _, jwk := range set.Keys {
+		if jwk.Kty != "RSA" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+
+		key, err := jwk.PublicKey()
+		if err != nil {
+			return nil, err
+		}
+
+		keys[jwk.Kid] = key
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,264 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/dexlabsio/garlic/errors"
+)
+
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+)
+
+// Claims are the claims carried by the tokens issued and accepted by garlic
+// services.
+type Claims struct {
+	jwt.RegisteredClaims
+	OrganizationId string   `json:"org_id,omitempty"`
+	IsSuperuser    bool     `json:"is_superuser,omitempty"`
+	Roles          []string `json:"roles,omitempty"`
+	Scopes         []string `json:"scopes,omitempty"`
+}
+
+// Principal converts the claims into the authenticated principal.
+func (c *Claims) Principal() *Principal {
+	return &Principal{
+		Subject:        c.Subject,
+		OrganizationId: c.OrganizationId,
+		Superuser:      c.IsSuperuser,
+		Roles:          c.Roles,
+		Scopes:         c.Scopes,
+		Method:         MethodJWT,
+	}
+}
+
+// JWTBackend issues and verifies bearer tokens.
+type JWTBackend struct {
+	config     *JWTConfig
+	method     jwt.SigningMethod
+	secret     []byte
+	privateKey *rsa.PrivateKey
+	keys       *KeySet
+}
+
+// NewJWTBackend builds a backend from the configuration, loading the
+// signing keys and the JWKS when RS256 is used.
+func NewJWTBackend(config *JWTConfig) (*JWTBackend, error) {
+	b := &JWTBackend{config: config}
+
+	switch config.Algorithm {
+	case AlgorithmHS256:
+		if config.Secret == "" || config.Secret == PlaceholderSecret {
+			// Anyone could forge tokens signed with a known secret.
+			return nil, errors.New(
+				errors.KindSystemError,
+				"a private secret is required to use HS256 tokens",
+				errors.Hint("Please set the JWT secret in the auth configuration."),
+			)
+		}
+
+		b.method = jwt.SigningMethodHS256
+		b.secret = []byte(config.Secret)
+
+	case AlgorithmRS256:
+		b.method = jwt.SigningMethodRS256
+
+		if config.PrivateKeyFile != "" {
+			pem, err := os.ReadFile(config.PrivateKeyFile)
+			if err != nil {
+				return nil, errors.Propagate(
+					err,
+					"failed to read private key file",
+					errors.Context(errors.Field("private_key_file", config.PrivateKeyFile)),
+				)
+			}
+
+			b.privateKey, err = jwt.ParseRSAPrivateKeyFromPEM(pem)
+			if err != nil {
+				return nil, errors.Propagate(
+					err,
+					"failed to parse private key",
+					errors.Context(errors.Field("private_key_file", config.PrivateKeyFile)),
+				)
+			}
+		}
+
+		switch {
+		case config.JWKSURL != "":
+			b.keys = NewRemoteKeySet(config.JWKSURL, config.JWKSRefreshInterval)
+		case config.JWKSFile != "":
+			keys, err := LoadKeySet(config.JWKSFile)
+			if err != nil {
+				return nil, err
+			}
+			b.keys = keys
+		case b.privateKey != nil:
+			b.keys = StaticKeySet(map[string]*rsa.PublicKey{config.KeyId: &b.privateKey.PublicKey})
+		default:
+			return nil, errors.New(
+				errors.KindSystemError,
+				"no key available to verify RS256 tokens",
+				errors.Hint("Please set a JWKS file, a JWKS URL or a private key in the auth configuration."),
+			)
+		}
+
+	default:
+		return nil, errors.New(
+			errors.KindSystemError,
+			"unsupported JWT algorithm",
+			errors.Context(errors.Field("algorithm", config.Algorithm)),
+			errors.Hint("Valid options are [HS256, RS256]."),
+		)
+	}
+
+	return b, nil
+}
+
+// NewJWT issues a token for the given user.
+func (b *JWTBackend) NewJWT(userId string, orgId string, isSuperuser bool) (string, error) {
+	return b.Sign(&Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: userId},
+		OrganizationId:   orgId,
+		IsSuperuser:      isSuperuser,
+	})
+}
+
+// Sign issues a token with the given claims. The issuer, audience and
+// expiration are filled from the configuration when not set.
+func (b *JWTBackend) Sign(claims *Claims) (string, error) {
+	now := time.Now()
+	if claims.IssuedAt == nil {
+		claims.IssuedAt = jwt.NewNumericDate(now)
+	}
+	if claims.ExpiresAt == nil && b.config.Expiration > 0 {
+		claims.ExpiresAt = jwt.NewNumericDate(now.Add(b.config.Expiration))
+	}
+	if claims.Issuer == "" {
+		claims.Issuer = b.config.Issuer
+	}
+	if len(claims.Audience) == 0 && b.config.Audience != "" {
+		claims.Audience = jwt.ClaimStrings{b.config.Audience}
+	}
+
+	token := jwt.NewWithClaims(b.method, claims)
+
+	var key any = b.secret
+	if b.method == jwt.SigningMethodRS256 {
+		if b.privateKey == nil {
+			return "", errors.New(
+				errors.KindSystemError,
+				"this service can't issue RS256 tokens",
+				errors.Hint("Please set a private key in the auth configuration."),
+			)
+		}
+
+		key = b.privateKey
+		if b.config.KeyId != "" {
+			token.Header["kid"] = b.config.KeyId
+		}
+	}
+
+	tokenStr, err := token.SignedString(key)
+	if err != nil {
+		return "", errors.Propagate(err, "failed to sign token")
+	}
+
+	return tokenStr, nil
+}
+
+// ParseJWT extracts the bearer token from the Authorization header and
+// verifies its signature and claims.
+func (b *JWTBackend) ParseJWT(r *http.Request) (*jwt.Token, error) {
+	tokenStr, ok := BearerToken(r)
+	if !ok {
+		return nil, errors.New(
+			KindAuthError,
+			"missing bearer token",
+			errors.Hint("Please provide a token in the Authorization header."),
+		)
+	}
+
+	return b.Parse(tokenStr)
+}
+
+// Parse verifies the signature and the claims of a token.
+func (b *JWTBackend) Parse(tokenStr string) (*jwt.Token, error) {
+	token, err := jwt.ParseWithClaims(
+		tokenStr,
+		&Claims{},
+		b.keyFunc,
+		jwt.WithValidMethods([]string{b.method.Alg()}),
+	)
+	if err != nil {
+		return nil, errors.PropagateAs(
+			KindAuthError,
+			err,
+			"invalid token",
+			errors.Hint("Please sign in again to get a new token."),
+		)
+	}
+
+	claims := token.Claims.(*Claims)
+	if b.config.Issuer != "" && !claims.VerifyIssuer(b.config.Issuer, true) {
+		return nil, errors.New(
+			KindAuthError,
+			"invalid token",
+			errors.Context(errors.Field("issuer", claims.Issuer)),
+			errors.Hint("The token was not issued by a trusted issuer."),
+		)
+	}
+
+	if b.config.Audience != "" && !claims.VerifyAudience(b.config.Audience, true) {
+		return nil, errors.New(
+			KindAuthError,
+			"invalid token",
+			errors.Context(errors.Field("audience", claims.Audience)),
+			errors.Hint("The token was not issued for this service."),
+		)
+	}
+
+	return token, nil
+}
+
+// Authenticate implements AuthBackend. Requests without a bearer token are
+// left to the next backend.
+func (b *JWTBackend) Authenticate(r *http.Request) (*Principal, error) {
+	if _, ok := BearerToken(r); !ok {
+		return nil, nil
+	}
+
+	token, err := b.ParseJWT(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return token.Claims.(*Claims).Principal(), nil
+}
+
+func (b *JWTBackend) keyFunc(token *jwt.Token) (any, error) {
+	if b.keys == nil {
+		return b.secret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	return b.keys.Key(kid)
+}
+
+// BearerToken returns the token of the Authorization header when it uses
+// the Bearer scheme.
+func BearerToken(r *http.Request) (string, bool) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/dexlabsio/garlic/errors"
+	"github.com/dexlabsio/garlic/logging"
+	"github.com/dexlabsio/garlic/rest"
+)
+
+// Authenticate is a middleware that requires requests to be authenticated
+// by one of the backends. Backends are tried in order and the first one
+// recognizing the credentials decides the outcome. The resulting principal
+// is stored in the request context and its subject added to the request
+// logger.
+func Authenticate(backends ...AuthBackend) rest.Middleware {
+	return authenticate(true, backends)
+}
+
+// Optional is like Authenticate, but lets anonymous requests through
+// without a principal. Requests with invalid credentials are still rejected.
+func Optional(backends ...AuthBackend) rest.Middleware {
+	return authenticate(false, backends)
+}
+
+func authenticate(required bool, backends []AuthBackend) rest.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, err := authenticateRequest(r, backends)
+			if err == nil && principal == nil && required {
+				err = errors.New(
+					KindAuthError,
+					"request is not authenticated",
+					errors.Hint("Please provide valid credentials and try again."),
+				)
+			}
+
+			if err != nil {
+				writeError(w, r, err)
+				return
+			}
+
+			if principal != nil {
+				ctx := SetContextPrincipal(r.Context(), principal)
+				l := logging.GetLoggerFromContextOrGlobal(ctx).With(
+					zap.String("principal", principal.Subject),
+					zap.String("auth_method", principal.Method),
+				)
+				r = r.WithContext(logging.SetContextLogger(ctx, l))
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireSuperuser is a middleware that only lets superusers through. It
+// must run after Authenticate.
+func RequireSuperuser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, err := GetPrincipalFromContext(r.Context())
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		if !principal.Superuser {
+			writeError(w, r, errors.New(
+				KindForbiddenError,
+				"operation restricted to superusers",
+				errors.Context(errors.Field("subject", principal.Subject)),
+			))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func authenticateRequest(r *http.Request, backends []AuthBackend) (*Principal, error) {
+	for _, backend := range backends {
+		principal, err := backend.Authenticate(r)
+		if err != nil {
+			return nil, err
+		}
+
+		if principal != nil {
+			return principal, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	l := logging.GetLoggerFromContextOrGlobal(r.Context())
+	if errors.IsKind(err, errors.KindUserError) {
+		l.Warn("[AUTH ERROR]", errors.Zap(err))
+	} else {
+		l.Error("[AUTH ERROR]", errors.Zap(err))
+	}
+
+	rest.WriteError(err).Must(w)
+}
@@ -0,0 +1,29 @@
+//go:build unit
+// +build unit
+
+package auth
+
+import "net/http"
+
+type AuthBackendMock struct {
+	principal *Principal
+	err       error
+}
+
+func NewAuthBackendMock(principal *Principal) *AuthBackendMock {
+	return &AuthBackendMock{
+		principal: principal,
+		err:       nil,
+	}
+}
+
+func NewInvalidAuthBackendMock(err error) *AuthBackendMock {
+	return &AuthBackendMock{
+		principal: nil,
+		err:       err,
+	}
+}
+
+func (ab *AuthBackendMock) Authenticate(r *http.Request) (*Principal, error) {
+	return ab.principal, ab.err
+}
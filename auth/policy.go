@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+
+	chi "github.com/go-chi/chi/v5"
+
+	"github.com/dexlabsio/garlic/errors"
+	"github.com/dexlabsio/garlic/rest"
+)
+
+// Wildcard grants every permission or scope under a prefix, as in
+// "orders:*", or everything when used alone.
+const Wildcard = "*"
+
+// OrganizationParam is the URL param checked by OrganizationRule.
+const OrganizationParam = "organization_id"
+
+// ResourceRule reports whether the principal may access the resource
+// identified by the value of a URL param.
+type ResourceRule func(principal *Principal, value string) bool
+
+// OrganizationRule only grants access to the organization of the principal.
+func OrganizationRule(principal *Principal, organizationId string) bool {
+	return principal.OrganizationId == organizationId
+}
+
+// Policy is the default rest.Authorizer. It grants the permissions of the
+// principal roles and its scopes, and restricts routes whose pattern has
+// a resource param, like {organization_id}, to principals owning the
+// resource. Superusers bypass every check.
+type Policy struct {
+	roles     map[string][]string
+	resources map[string]ResourceRule
+}
+
+// NewPolicy creates a policy with the given role permissions, restricting
+// the organization_id param with OrganizationRule.
+func NewPolicy(roles map[string][]string) *Policy {
+	return &Policy{
+		roles: roles,
+		resources: map[string]ResourceRule{
+			OrganizationParam: OrganizationRule,
+		},
+	}
+}
+
+// WithResource restricts the routes having the given URL param with rule.
+// A nil rule removes the restriction.
+func (p *Policy) WithResource(param string, rule ResourceRule) *Policy {
+	if rule == nil {
+		delete(p.resources, param)
+	} else {
+		p.resources[param] = rule
+	}
+	return p
+}
+
+// Permissions returns the permissions granted by the roles of the principal.
+func (p *Policy) Permissions(principal *Principal) []string {
+	permissions := []string{}
+	for _, role := range principal.Roles {
+		permissions = append(permissions, p.roles[role]...)
+	}
+	return permissions
+}
+
+// Authorize implements rest.Authorizer.
+func (p *Policy) Authorize(r *http.Request, requirement *rest.Authorization) error {
+	principal, err := GetPrincipalFromContext(r.Context())
+	if err != nil {
+		return err
+	}
+
+	if principal.Superuser {
+		return nil
+	}
+
+	granted := p.Permissions(principal)
+	for _, permission := range requirement.Permissions {
+		if !Grants(granted, permission) {
+			return errors.New(
+				KindForbiddenError,
+				"permission denied",
+				errors.Context(
+					errors.Field("subject", principal.Subject),
+					errors.Field("permission", permission),
+				),
+				errors.Hint("You need the '%s' permission to perform this operation.", permission),
+			)
+		}
+	}
+
+	for _, scope := range requirement.Scopes {
+		if !Grants(principal.Scopes, scope) {
+			return errors.New(
+				KindForbiddenError,
+				"scope denied",
+				errors.Context(
+					errors.Field("subject", principal.Subject),
+					errors.Field("scope", scope),
+				),
+				errors.Hint("Your credentials need the '%s' scope to perform this operation.", scope),
+			)
+		}
+	}
+
+	for param, rule := range p.resources {
+		value := chi.URLParam(r, param)
+		if value == "" {
+			continue
+		}
+
+		if !rule(principal, value) {
+			return errors.New(
+				KindForbiddenError,
+				"access to resource denied",
+				errors.Context(
+					errors.Field("subject", principal.Subject),
+					errors.Field(param, value),
+				),
+				errors.Hint("You don't have access to the requested %s.", strings.TrimSuffix(param, "_id")),
+			)
+		}
+	}
+
+	return nil
+}
+
+// Grants reports whether the granted permissions include the required one,
+// either literally or through a wildcard.
+func Grants(granted []string, required string) bool {
+	for _, g := range granted {
+		if g == required || g == Wildcard {
+			return true
+		}
+
+		if prefix, ok := strings.CutSuffix(g, Wildcard); ok && strings.HasPrefix(required, prefix) {
+			return true
+		}
+	}
+	return false
+}
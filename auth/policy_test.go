@@ -0,0 +1,73 @@
+//go:build unit
+// +build unit
+
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	chi "github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/dexlabsio/garlic/logging"
+	"github.com/dexlabsio/garlic/rest"
+)
+
+type ordersApp struct{}
+
+func (ordersApp) Routes() rest.Routes {
+	ok := func(w http.ResponseWriter, r *http.Request) error {
+		w.WriteHeader(http.StatusOK)
+		return nil
+	}
+
+	return rest.Routes{
+		rest.Get("/organizations/{organization_id}/orders", ok).Require("orders:read"),
+		rest.Delete("/organizations/{organization_id}/orders", ok).Require("orders:delete").RequireScopes("write"),
+		rest.Get("/organizations/{organization_id}", ok).Require(),
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	rest.SetAuthorizer(NewPolicy(map[string][]string{
+		"viewer": {"orders:read"},
+		"admin":  {"orders:*"},
+	}))
+	defer rest.SetAuthorizer(nil)
+
+	router := chi.NewRouter()
+	rest.RegisterApp(router, ordersApp{})
+
+	cases := []struct {
+		name      string
+		principal *Principal
+		method    string
+		url       string
+		status    int
+	}{
+		{"anonymous", nil, http.MethodGet, "/organizations/o1/orders", http.StatusUnauthorized},
+		{"granted by role", &Principal{OrganizationId: "o1", Roles: []string{"viewer"}}, http.MethodGet, "/organizations/o1/orders", http.StatusOK},
+		{"missing permission", &Principal{OrganizationId: "o1", Roles: []string{"viewer"}}, http.MethodDelete, "/organizations/o1/orders", http.StatusForbidden},
+		{"missing scope", &Principal{OrganizationId: "o1", Roles: []string{"admin"}}, http.MethodDelete, "/organizations/o1/orders", http.StatusForbidden},
+		{"granted by wildcard", &Principal{OrganizationId: "o1", Roles: []string{"admin"}, Scopes: []string{"write"}}, http.MethodDelete, "/organizations/o1/orders", http.StatusOK},
+		{"other organization", &Principal{OrganizationId: "o2", Roles: []string{"viewer"}}, http.MethodGet, "/organizations/o1/orders", http.StatusForbidden},
+		{"resource only", &Principal{OrganizationId: "o1"}, http.MethodGet, "/organizations/o1", http.StatusOK},
+		{"superuser", &Principal{Superuser: true}, http.MethodDelete, "/organizations/o1/orders", http.StatusOK},
+	}
+
+	for _, c := range cases {
+		ctx := logging.SetContextLogger(context.Background(), zap.NewNop())
+		if c.principal != nil {
+			ctx = SetContextPrincipal(ctx, c.principal)
+		}
+		req := httptest.NewRequest(c.method, c.url, nil).WithContext(ctx)
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		assert.Equal(t, c.status, rec.Code, c.name)
+	}
+}
@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"context"
+	"slices"
+
+	"github.com/dexlabsio/garlic/errors"
+)
+
+type key int
+
+const (
+	PrincipalKey key = iota
+)
+
+const (
+	MethodJWT    = "jwt"
+	MethodAPIKey = "apikey"
+)
+
+// Principal is the authenticated identity performing a request.
+type Principal struct {
+	Subject        string   `json:"subject"`
+	OrganizationId string   `json:"organization_id,omitempty"`
+	Superuser      bool     `json:"superuser"`
+	Roles          []string `json:"roles,omitempty"`
+	Scopes         []string `json:"scopes,omitempty"`
+	Method         string   `json:"method"`
+}
+
+// HasRole reports whether the principal was granted the given role.
+func (p *Principal) HasRole(role string) bool {
+	return slices.Contains(p.Roles, role)
+}
+
+// HasScope reports whether the principal was granted the given scope.
+func (p *Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+// GetPrincipalFromContext is a helper function that retrieves the authenticated
+// principal from a context
+func GetPrincipalFromContext(ctx context.Context) (*Principal, error) {
+	val := ctx.Value(PrincipalKey)
+	if val == nil {
+		return nil, errors.New(
+			KindAuthError,
+			"request is not authenticated",
+			errors.Hint("Please provide valid credentials and try again."),
+		)
+	}
+
+	principal, ok := val.(*Principal)
+	if !ok {
+		return nil, errors.New(
+			KindContextError,
+			"invalid principal found in context",
+			errors.Context(
+				errors.Field("invalid_principal", val),
+			),
+		)
+	}
+
+	return principal, nil
+}
+
+// SetContextPrincipal is a helper function that associates the authenticated
+// principal with a context, allowing it to be retrieved by the next layers.
+func SetContextPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, PrincipalKey, principal)
+}
@@ -0,0 +1,397 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	pgx "github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
+
+	"github.com/dexlabsio/garlic/database/utils"
+	"github.com/dexlabsio/garlic/errors"
+)
+
+// MaxBindParams is the most arguments postgres accepts in a statement.
+// CreateMany splits its inserts in batches within this limit.
+const MaxBindParams = 65535
+
+// OnConflict describes the ON CONFLICT clause of an upsert. The conflict is
+// detected on the Target columns or, alternatively, on a named Constraint.
+// The Update columns are overwritten with the values being inserted; when
+// there is none, conflicting rows are left as they are.
+type OnConflict struct {
+	Target     []string
+	Constraint string
+	Update     []string
+}
+
+// Clause builds the ON CONFLICT clause, prefixed with a space.
+func (c *OnConflict) Clause() string {
+	var b strings.Builder
+	b.WriteString(" ON CONFLICT")
+
+	switch {
+	case c.Constraint != "":
+		b.WriteString(" ON CONSTRAINT " + quoteIdentifier(c.Constraint))
+	case len(c.Target) > 0:
+		b.WriteString(" (" + ColumnList(c.Target...) + ")")
+	}
+
+	if len(c.Update) == 0 {
+		b.WriteString(" DO NOTHING")
+		return b.String()
+	}
+
+	assignments := make([]string, len(c.Update))
+	for i, column := range c.Update {
+		assignments[i] = quoteIdentifier(column) + " = EXCLUDED." + quoteIdentifier(column)
+	}
+	b.WriteString(" DO UPDATE SET " + strings.Join(assignments, ", "))
+
+	return b.String()
+}
+
+// CreateMany inserts the resources, a slice of structs or of pointers to
+// structs, with a multi-row insert. The query is written as for Create,
+// with a single VALUES tuple of named parameters that is repeated for each
+// resource. When the query has a RETURNING clause, the returned rows are
+// scanned back into the resources in the order they were given.
+//
+// Slices larger than MaxBindParams allows are inserted in batches, in a
+// transaction or a savepoint of the current one, so either all resources
+// are inserted or none.
+func (db *Database) CreateMany(ctx context.Context, query string, resources any) (err error) {
+	ctx, end := db.instrument(ctx, opCreateMany, query)
+	defer end(&err)
+
+	return db.createMany(ctx, query, resources)
+}
+
+func (db *Database) createMany(ctx context.Context, query string, resources any) error {
+	ectx := errors.Context(
+		errors.Field("query", query),
+	)
+
+	slice, err := sliceOf(resources)
+	if err != nil {
+		return errors.Propagate(err, "invalid resources to insert", ectx)
+	}
+
+	if slice.Len() == 0 {
+		return nil
+	}
+
+	_, params, err := sqlx.Named(query, slice.Index(0).Interface())
+	if err != nil {
+		return errors.PropagateAs(errors.KindSystemError, err, "failed to bind named query", ectx)
+	}
+
+	size := slice.Len()
+	if len(params) > 0 {
+		size = max(1, MaxBindParams/len(params))
+	}
+
+	if slice.Len() <= size {
+		return db.createBatch(ctx, query, slice, ectx)
+	}
+
+	ctxTx, commit, rollback, err := db.BeginContext(ctx)
+	if err != nil {
+		return errors.Propagate(err, "failed to begin batch insert", ectx)
+	}
+
+	for start := 0; start < slice.Len(); start += size {
+		end := min(start+size, slice.Len())
+		if err := db.createBatch(ctxTx, query, slice.Slice(start, end), ectx); err != nil {
+			_ = rollback()
+			return errors.Propagate(err, "failed to insert batch of resources", errors.Context(
+				errors.Field("start", start),
+				errors.Field("end", end),
+			))
+		}
+	}
+
+	if err := commit(); err != nil {
+		return errors.Propagate(err, "failed to commit batch insert", ectx)
+	}
+
+	return nil
+}
+
+// createBatch inserts a batch of resources with a single statement.
+func (db *Database) createBatch(ctx context.Context, query string, batch reflect.Value, ectx *errors.ContextT) error {
+	rows, err := db.Executor(ctx).NamedQuery(query, batch.Interface())
+	if err != nil {
+		return TranslateError(errors.KindSystemError, err, "failed to insert resources", ectx)
+	}
+	defer rows.Close()
+
+	scanned := 0
+	for rows.Next() {
+		if scanned >= batch.Len() {
+			return errors.New(errors.KindSystemError, "more rows returned than resources inserted", ectx)
+		}
+
+		if err := rows.StructScan(pointerTo(batch.Index(scanned))); err != nil {
+			return errors.PropagateAs(errors.KindSystemError, err, "failed to scan returned resource", ectx)
+		}
+		scanned++
+	}
+
+	if err := rows.Err(); err != nil {
+		return TranslateError(errors.KindSystemError, err, "failed to insert resources", ectx)
+	}
+
+	if scanned > 0 && scanned != batch.Len() {
+		return errors.New(
+			errors.KindSystemError,
+			"returned rows don't match the resources inserted",
+			ectx,
+			errors.Context(
+				errors.Field("inserted", batch.Len()),
+				errors.Field("returned", scanned),
+			),
+		)
+	}
+
+	return nil
+}
+
+// Upsert inserts the resource, or a slice of them, updating the existing
+// rows on conflict as described by conflict. The query is an INSERT without
+// RETURNING, which is added by Upsert to scan the resulting rows back into
+// the resources. Rows left as they are on conflict are not returned by
+// postgres, so a slice is only scanned back when conflict updates columns.
+//
+// A slice is upserted with multi-row inserts, whose returned rows follow
+// the order of the resources. Postgres can't update a row twice in the
+// same statement, so the conflict keys of the resources must be unique;
+// with a conflict Target, duplicates are rejected before querying.
+func (db *Database) Upsert(ctx context.Context, query string, resource any, conflict *OnConflict) (err error) {
+	query += conflict.Clause()
+	ctx, end := db.instrument(ctx, opUpsert, query)
+	defer end(&err)
+
+	if reflect.Indirect(reflect.ValueOf(resource)).Kind() == reflect.Slice {
+		if err := db.uniqueConflictKeys(resource, conflict); err != nil {
+			return errors.Propagate(err, "invalid resources to upsert")
+		}
+
+		if len(conflict.Update) > 0 {
+			query += " RETURNING *"
+		}
+
+		if err := db.createMany(ctx, query, resource); err != nil {
+			return errors.Propagate(err, "failed to upsert resources")
+		}
+
+		return nil
+	}
+
+	query += " RETURNING *"
+	ectx := errors.Context(
+		errors.Field("query", query),
+	)
+
+	rows, err := db.Executor(ctx).NamedQuery(query, resource)
+	if err != nil {
+		return TranslateError(errors.KindSystemError, err, "failed to upsert resource", ectx)
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		if err := rows.StructScan(resource); err != nil {
+			return errors.PropagateAs(errors.KindSystemError, err, "failed to scan returned resource", ectx)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return TranslateError(errors.KindSystemError, err, "failed to upsert resource", ectx)
+	}
+
+	return nil
+}
+
+// CopyFrom loads the resources, a slice of structs or of pointers to
+// structs, into the table with the COPY protocol, which is much faster than
+// inserts for large batches but returns nothing. The columns are read from
+// the resources through their db tags; when none is given, every column
+// mapped by the resources is copied. It runs in the transaction of the
+// context, if any, and returns the number of rows copied.
+func (db *Database) CopyFrom(ctx context.Context, table string, columns []string, resources any) (_ int64, err error) {
+	ctx, end := db.instrument(ctx, opCopy, "COPY "+table)
+	defer end(&err)
+
+	ectx := errors.Context(
+		errors.Field("table", table),
+		errors.Field("columns", columns),
+	)
+
+	slice, err := sliceOf(resources)
+	if err != nil {
+		return 0, errors.Propagate(err, "invalid resources to copy", ectx)
+	}
+
+	if len(columns) == 0 {
+		columns = utils.Columns(reflect.New(slice.Type().Elem()).Interface())
+	}
+
+	source := pgx.CopyFromSlice(slice.Len(), func(i int) ([]any, error) {
+		item := reflect.Indirect(slice.Index(i))
+		if !item.IsValid() {
+			return nil, errors.New(errors.KindSystemError, "resources can't be nil", errors.Context(errors.Field("index", i)))
+		}
+
+		values := make([]any, len(columns))
+		for j, column := range columns {
+			field, ok := fieldByColumn(db.Mapper, item, column)
+			if !ok {
+				return nil, errors.New(
+					errors.KindSystemError,
+					"column is not mapped by the resource",
+					errors.Context(errors.Field("column", column)),
+				)
+			}
+			values[j] = field.Interface()
+		}
+
+		return values, nil
+	})
+
+	var copied int64
+	err = db.withConn(ctx, func(conn *pgx.Conn) error {
+		copied, err = conn.CopyFrom(ctx, pgx.Identifier(strings.Split(table, ".")), columns, source)
+		return err
+	})
+	if err != nil {
+		return 0, TranslateError(errors.KindSystemError, err, "failed to copy resources", ectx)
+	}
+
+	return copied, nil
+}
+
+// withConn runs fn with the native pgx connection of the transaction of the
+// context or, without one, of a connection taken from the pool.
+func (db *Database) withConn(ctx context.Context, fn func(*pgx.Conn) error) error {
+	conn := Connection(ctx)
+	if conn == nil {
+		var err error
+		if conn, err = db.Connx(ctx); err != nil {
+			return err
+		}
+		defer conn.Close()
+	}
+
+	return conn.Raw(func(driverConn any) error {
+		stdConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return errors.New(
+				errors.KindSystemError,
+				"native connection is only available with the pgx driver",
+				errors.Context(errors.Field("driver", reflect.TypeOf(driverConn).String())),
+			)
+		}
+
+		return fn(stdConn.Conn())
+	})
+}
+
+// sliceOf dereferences resources into the slice of resources it holds,
+// which must be structs or pointers to structs.
+func sliceOf(resources any) (reflect.Value, error) {
+	slice := reflect.Indirect(reflect.ValueOf(resources))
+	if slice.Kind() != reflect.Slice || reflectx.Deref(slice.Type().Elem()).Kind() != reflect.Struct {
+		return slice, errors.New(
+			errors.KindSystemError,
+			"resources must be a slice of structs",
+			errors.Context(errors.Field("type", fmt.Sprintf("%T", resources))),
+		)
+	}
+
+	return slice, nil
+}
+
+// uniqueConflictKeys checks that no two resources share the values of the
+// conflict Target columns.
+func (db *Database) uniqueConflictKeys(resources any, conflict *OnConflict) error {
+	if len(conflict.Target) == 0 {
+		return nil
+	}
+
+	slice, err := sliceOf(resources)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]int, slice.Len())
+	for i := 0; i < slice.Len(); i++ {
+		item := reflect.Indirect(slice.Index(i))
+		if !item.IsValid() {
+			return errors.New(errors.KindSystemError, "resources can't be nil", errors.Context(errors.Field("index", i)))
+		}
+
+		key := make([]any, len(conflict.Target))
+		for j, column := range conflict.Target {
+			field, ok := fieldByColumn(db.Mapper, item, column)
+			if !ok {
+				return errors.New(
+					errors.KindSystemError,
+					"conflict column is not mapped by the resource",
+					errors.Context(errors.Field("column", column)),
+				)
+			}
+			key[j] = derefValue(field)
+		}
+
+		raw, err := json.Marshal(key)
+		if err != nil {
+			return errors.PropagateAs(errors.KindSystemError, err, "failed to encode conflict key", errors.Context(
+				errors.Field("index", i),
+			))
+		}
+
+		encoded := string(raw)
+		if first, ok := seen[encoded]; ok {
+			return errors.New(
+				errors.KindSystemError,
+				"resources share a conflict key",
+				errors.Hint("Postgres can't update the same row twice in an upsert, please merge them first."),
+				errors.Context(
+					errors.Field("target", conflict.Target),
+					errors.Field("first", first),
+					errors.Field("second", i),
+				),
+			)
+		}
+		seen[encoded] = i
+	}
+
+	return nil
+}
+
+// derefValue returns the value held by the field, following pointers so
+// equal values compare equal, or nil for a nil pointer.
+func derefValue(field reflect.Value) any {
+	for field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			return nil
+		}
+		field = field.Elem()
+	}
+
+	return field.Interface()
+}
+
+// pointerTo returns a pointer to the resource held by the slice element.
+func pointerTo(item reflect.Value) any {
+	if item.Kind() == reflect.Ptr {
+		return item.Interface()
+	}
+
+	return item.Addr().Interface()
+}
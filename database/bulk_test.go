@@ -0,0 +1,133 @@
+//go:build unit
+// +build unit
+
+package database
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dexlabsio/garlic/errors"
+)
+
+type bulkItem struct {
+	Id    int    `db:"id"`
+	Name  string `db:"name"`
+	Price int    `db:"price"`
+}
+
+func TestOnConflictClause(t *testing.T) {
+	assert.Equal(t,
+		` ON CONFLICT ("sku") DO UPDATE SET "name" = EXCLUDED."name", "price" = EXCLUDED."price"`,
+		(&OnConflict{Target: []string{"sku"}, Update: []string{"name", "price"}}).Clause(),
+	)
+	assert.Equal(t,
+		` ON CONFLICT ON CONSTRAINT "items_sku_key" DO NOTHING`,
+		(&OnConflict{Constraint: "items_sku_key"}).Clause(),
+	)
+}
+
+func TestCreateMany(t *testing.T) {
+	rec, sqlDB := newRecorder()
+	db := &Database{config: Defaults(), DB: sqlDB}
+	query := "INSERT INTO items (id, name, price) VALUES (:id, :name, :price)"
+
+	err := db.CreateMany(context.Background(), query, []bulkItem{{1, "a", 10}, {2, "b", 20}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"INSERT INTO items (id, name, price) VALUES ($1, $2, $3),($4, $5, $6)",
+	}, rec.Statements())
+
+	assert.NoError(t, db.CreateMany(context.Background(), query, []bulkItem{}))
+	assert.Len(t, rec.Statements(), 1)
+}
+
+func TestCreateManyBatches(t *testing.T) {
+	rec, sqlDB := newRecorder()
+	db := &Database{config: Defaults(), DB: sqlDB}
+	query := "INSERT INTO items (id, name, price) VALUES (:id, :name, :price)"
+
+	// Three params per row fit 21845 rows in a statement.
+	items := make([]*bulkItem, MaxBindParams/3+1)
+	for i := range items {
+		items[i] = &bulkItem{Id: i}
+	}
+
+	assert.NoError(t, db.CreateMany(context.Background(), query, items))
+
+	statements := rec.Statements()
+	assert.Len(t, statements, 4)
+	assert.Equal(t, "BEGIN", statements[0])
+	assert.True(t, strings.HasSuffix(statements[1], "($65533, $65534, $65535)"))
+	assert.Equal(t, "INSERT INTO items (id, name, price) VALUES ($1, $2, $3)", statements[2])
+	assert.Equal(t, "COMMIT", statements[3])
+}
+
+func TestUpsertTranslatesErrors(t *testing.T) {
+	rec, sqlDB := newRecorder()
+	rec.fail = func(string) error { return &pgconn.PgError{Code: "23503"} }
+	db := &Database{config: Defaults(), DB: sqlDB}
+
+	err := db.Upsert(
+		context.Background(),
+		"INSERT INTO items (id, name, price) VALUES (:id, :name, :price)",
+		&bulkItem{Id: 1},
+		&OnConflict{Target: []string{"id"}, Update: []string{"price"}},
+	)
+	assert.True(t, errors.IsKind(err, KindDatabaseForeignKeyViolationError))
+	assert.Equal(t, []string{
+		`INSERT INTO items (id, name, price) VALUES ($1, $2, $3) ON CONFLICT ("id") DO UPDATE SET "price" = EXCLUDED."price" RETURNING *`,
+	}, rec.Statements())
+}
+
+func TestUpsertRejectsDuplicateConflictKeys(t *testing.T) {
+	rec, sqlDB := newRecorder()
+	db := &Database{config: Defaults(), DB: sqlDB}
+
+	err := db.Upsert(
+		context.Background(),
+		"INSERT INTO items (id, name, price) VALUES (:id, :name, :price)",
+		[]bulkItem{{1, "a", 10}, {2, "b", 20}, {1, "c", 30}},
+		&OnConflict{Target: []string{"id"}, Update: []string{"price"}},
+	)
+	assert.True(t, errors.IsKind(err, errors.KindSystemError))
+	assert.Empty(t, rec.Statements())
+}
+
+func TestUpsertConflictKeys(t *testing.T) {
+	_, sqlDB := newRecorder()
+	db := &Database{config: Defaults(), DB: sqlDB}
+
+	type account struct {
+		Tenant string  `db:"tenant"`
+		Name   string  `db:"name"`
+		Email  *string `db:"email"`
+	}
+
+	// Composite keys whose values concatenate alike are distinct.
+	composite := &OnConflict{Target: []string{"tenant", "name"}}
+	assert.NoError(t, db.uniqueConflictKeys([]account{{Tenant: "ab", Name: "c"}, {Tenant: "a", Name: "bc"}}, composite))
+
+	// Pointer fields are compared by the values they point to.
+	first, second := "john@example.com", "john@example.com"
+	byEmail := &OnConflict{Target: []string{"email"}}
+	err := db.uniqueConflictKeys([]account{{Email: &first}, {Email: &second}}, byEmail)
+	assert.True(t, errors.IsKind(err, errors.KindSystemError))
+}
+
+func TestBulkRejectsNonStructSlices(t *testing.T) {
+	_, sqlDB := newRecorder()
+	db := &Database{config: Defaults(), DB: sqlDB}
+
+	assert.NotPanics(t, func() {
+		_, err := db.CopyFrom(context.Background(), "items", nil, []int{1, 2})
+		assert.True(t, errors.IsKind(err, errors.KindSystemError))
+	})
+
+	err := db.CreateMany(context.Background(), "INSERT INTO items (id) VALUES (:id)", []string{"a"})
+	assert.True(t, errors.IsKind(err, errors.KindSystemError))
+}
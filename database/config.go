@@ -4,21 +4,61 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 )
 
-var ErrConfigInvalidSSLMode = errors.New("invalid SSLMode; valid options are [require, disable]")
+const (
+	// DefaultReplicaCheckPeriod is how often replicas are checked by default.
+	DefaultReplicaCheckPeriod = 10 * time.Second
+
+	// DefaultLockCheckPeriod is how often the connections holding locks are
+	// checked by default.
+	DefaultLockCheckPeriod = 5 * time.Second
+)
+
+var (
+	ErrConfigInvalidSSLMode = errors.New("invalid SSLMode; valid options are [disable, allow, prefer, require, verify-ca, verify-full]")
+	ErrConfigInvalidDriver  = errors.New("invalid Driver; valid options are [stdlib, pgxpool]")
+)
 
 type SSLMode string
 
 const (
-	SSLModeRequire SSLMode = "require"
-	SSLModeDisable SSLMode = "disable"
+	SSLModeDisable    SSLMode = "disable"
+	SSLModeAllow      SSLMode = "allow"
+	SSLModePrefer     SSLMode = "prefer"
+	SSLModeRequire    SSLMode = "require"
+	SSLModeVerifyCA   SSLMode = "verify-ca"
+	SSLModeVerifyFull SSLMode = "verify-full"
 )
 
 var (
 	SSLModes = map[SSLMode]struct{}{
-		SSLModeRequire: {},
-		SSLModeDisable: {},
+		SSLModeDisable:    {},
+		SSLModeAllow:      {},
+		SSLModePrefer:     {},
+		SSLModeRequire:    {},
+		SSLModeVerifyCA:   {},
+		SSLModeVerifyFull: {},
+	}
+)
+
+// Driver selects how connections are pooled.
+type Driver string
+
+const (
+	// DriverStdlib pools connections with database/sql.
+	DriverStdlib Driver = "stdlib"
+
+	// DriverPgxPool pools connections with pgxpool, which also gives access
+	// to native pgx features such as COPY and batches through Database.Pool.
+	DriverPgxPool Driver = "pgxpool"
+)
+
+var (
+	Drivers = map[Driver]struct{}{
+		DriverStdlib:  {},
+		DriverPgxPool: {},
 	}
 )
 
@@ -31,21 +71,77 @@ type Config struct {
 	Username string  `mapstructure:"username" yaml:"username"`
 	Password string  `mapstructure:"password" yaml:"password"`
 	SSLMode  SSLMode `mapstructure:"sslmode" yaml:"sslmode"`
+
+	// TLS files, as in libpq. The root certificate is needed by the
+	// verify-ca and verify-full modes, the client pair by mTLS setups.
+	SSLRootCert string `mapstructure:"sslrootcert" yaml:"sslrootcert"`
+	SSLCert     string `mapstructure:"sslcert" yaml:"sslcert"`
+	SSLKey      string `mapstructure:"sslkey" yaml:"sslkey"`
+
+	// Session settings applied to every connection.
+	ApplicationName  string        `mapstructure:"application_name" yaml:"application_name"`
+	SearchPath       string        `mapstructure:"search_path" yaml:"search_path"`
+	ConnectTimeout   time.Duration `mapstructure:"connect_timeout" yaml:"connect_timeout"`
+	StatementTimeout time.Duration `mapstructure:"statement_timeout" yaml:"statement_timeout"`
+
+	Driver Driver      `mapstructure:"driver" yaml:"driver"`
+	Pool   *PoolConfig `mapstructure:"pool" yaml:"pool"`
+
+	// Replicas are the DSNs of read replicas, in the URL or key=value
+	// format, used as they are with the driver and pool of the primary.
+	// Unreachable replicas are ejected from reads every ReplicaCheckPeriod
+	// until they answer again.
+	Replicas           []string      `mapstructure:"replicas" yaml:"replicas"`
+	ReplicaCheckPeriod time.Duration `mapstructure:"replica_check_period" yaml:"replica_check_period"`
+
+	// SlowQueryThreshold is the duration from which Store operations are
+	// logged as slow queries. Zero disables the log.
+	SlowQueryThreshold time.Duration `mapstructure:"slow_query_threshold" yaml:"slow_query_threshold"`
+
+	// LockCheckPeriod is how often the connections holding advisory locks
+	// are checked, bounding how long the loss of a lock goes unnoticed.
+	LockCheckPeriod time.Duration `mapstructure:"lock_check_period" yaml:"lock_check_period"`
+}
+
+// PoolConfig sizes the connection pool. Zero values keep the defaults of
+// the driver.
+type PoolConfig struct {
+	MaxOpenConns      int           `mapstructure:"max_open_conns" yaml:"max_open_conns"`
+	MaxIdleConns      int           `mapstructure:"max_idle_conns" yaml:"max_idle_conns"`
+	MinConns          int           `mapstructure:"min_conns" yaml:"min_conns"`
+	ConnMaxLifetime   time.Duration `mapstructure:"conn_max_lifetime" yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime   time.Duration `mapstructure:"conn_max_idle_time" yaml:"conn_max_idle_time"`
+	HealthCheckPeriod time.Duration `mapstructure:"health_check_period" yaml:"health_check_period"`
 }
 
 func Defaults() *Config {
 	return &Config{
-		Host:     "0.0.0.0",
-		Port:     5432,
-		Database: "postgres",
-		Username: "postgres",
-		Password: "postgres",
-		SSLMode:  SSLModeDisable,
+		Host:               "0.0.0.0",
+		Port:               5432,
+		Database:           "postgres",
+		Username:           "postgres",
+		Password:           "postgres",
+		SSLMode:            SSLModeDisable,
+		ConnectTimeout:     10 * time.Second,
+		Driver:             DriverStdlib,
+		Pool:               PoolConfigDefaults(),
+		ReplicaCheckPeriod: DefaultReplicaCheckPeriod,
+		SlowQueryThreshold: time.Second,
+		LockCheckPeriod:    DefaultLockCheckPeriod,
+	}
+}
+
+func PoolConfigDefaults() *PoolConfig {
+	return &PoolConfig{
+		MaxOpenConns:    25,
+		MaxIdleConns:    25,
+		ConnMaxLifetime: time.Hour,
+		ConnMaxIdleTime: 30 * time.Minute,
 	}
 }
 
 // marshalJSON unmarshals a JSON string into a SSLMode and
-// checks if it's a valid supported option [require, disable]
+// checks if it's a valid supported option
 func (s *SSLMode) UnmarshalJSON(data []byte) error {
 	var mode string
 	if err := json.Unmarshal(data, &mode); err != nil {
@@ -60,3 +156,20 @@ func (s *SSLMode) UnmarshalJSON(data []byte) error {
 	*s = ssm
 	return nil
 }
+
+// UnmarshalJSON unmarshals a JSON string into a Driver and
+// checks if it's a valid supported option [stdlib, pgxpool]
+func (d *Driver) UnmarshalJSON(data []byte) error {
+	var driver string
+	if err := json.Unmarshal(data, &driver); err != nil {
+		return fmt.Errorf("[database] failed unmarshalling database config: %w", err)
+	}
+
+	drv := Driver(driver)
+	if _, valid := Drivers[drv]; !valid {
+		return fmt.Errorf("[database] failed validating database config: %w", ErrConfigInvalidDriver)
+	}
+
+	*d = drv
+	return nil
+}
@@ -0,0 +1,76 @@
+//go:build unit
+// +build unit
+
+package database
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	pgx "github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnectionString(t *testing.T) {
+	config := Defaults()
+	config.Password = `p@ss 'word\`
+	config.SSLMode = SSLModeVerifyFull
+	config.SSLRootCert = "/certs/root.pem"
+	config.ApplicationName = "garlic test"
+	config.SearchPath = "app,public"
+	config.StatementTimeout = 1500 * time.Millisecond
+	config.ConnectTimeout = 1500 * time.Millisecond
+
+	db := New(config)
+	assert.Equal(
+		t,
+		`host='0.0.0.0' port='5432' user='postgres' password='p@ss \'word\\' dbname='postgres' `+
+			`sslmode='verify-full' sslrootcert='/certs/root.pem' application_name='garlic test' `+
+			`search_path='app,public' connect_timeout='2' statement_timeout='1500'`,
+		db.BuildConnectionString(),
+	)
+
+	// Parsing fails on missing certificate files, which proves the TLS
+	// options reach pgx, so check the session settings without them.
+	config.SSLMode = SSLModeDisable
+	config.SSLRootCert = ""
+
+	cfg, err := pgx.ParseConfig(db.BuildConnectionString())
+	assert.NoError(t, err)
+	assert.Equal(t, `p@ss 'word\`, cfg.Password)
+	assert.Equal(t, "garlic test", cfg.RuntimeParams["application_name"])
+	assert.Equal(t, "app,public", cfg.RuntimeParams["search_path"])
+	assert.Equal(t, "1500", cfg.RuntimeParams["statement_timeout"])
+	assert.Equal(t, 2*time.Second, cfg.ConnectTimeout)
+
+	u, err := url.Parse(db.BuildConnectionURL())
+	assert.NoError(t, err)
+	password, _ := u.User.Password()
+	assert.Equal(t, `p@ss 'word\`, password)
+	assert.Equal(t, "garlic test", u.Query().Get("application_name"))
+}
+
+type poolRecorder struct {
+	calls map[string]any
+}
+
+func (p *poolRecorder) SetMaxOpenConns(n int)              { p.calls["max_open_conns"] = n }
+func (p *poolRecorder) SetMaxIdleConns(n int)              { p.calls["max_idle_conns"] = n }
+func (p *poolRecorder) SetConnMaxLifetime(d time.Duration) { p.calls["conn_max_lifetime"] = d }
+func (p *poolRecorder) SetConnMaxIdleTime(d time.Duration) { p.calls["conn_max_idle_time"] = d }
+
+func TestConfigurePoolKeepsDefaults(t *testing.T) {
+	recorder := &poolRecorder{calls: map[string]any{}}
+	configurePool(recorder, &PoolConfig{MaxOpenConns: 10})
+	assert.Equal(t, map[string]any{"max_open_conns": 10}, recorder.calls)
+
+	recorder = &poolRecorder{calls: map[string]any{}}
+	configurePool(recorder, PoolConfigDefaults())
+	assert.Equal(t, map[string]any{
+		"max_open_conns":     25,
+		"max_idle_conns":     25,
+		"conn_max_lifetime":  time.Hour,
+		"conn_max_idle_time": 30 * time.Minute,
+	}, recorder.calls)
+}
@@ -3,11 +3,17 @@ package database
 import (
 	"context"
 	"database/sql"
-	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/dexlabsio/garlic/errors"
 	pgx "github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/jmoiron/sqlx"
 )
@@ -22,58 +28,219 @@ type Executor interface {
 
 type Database struct {
 	config *Config
+	pool   *pgxpool.Pool
 	*sqlx.DB
+
+	// Read replicas, chosen in turns by Reader.
+	replicas     []*replica
+	nextReplica  atomic.Uint64
+	stopReplicas context.CancelFunc
 }
 
 func New(config *Config) *Database {
 	return &Database{config: config}
 }
 
+// connectionParams lists the connection options in the libpq format.
+// Unknown keys, like statement_timeout, are sent by pgx as session
+// settings.
+func (db *Database) connectionParams() [][2]string {
+	c := db.config
+	params := [][2]string{{"sslmode", string(c.SSLMode)}}
+
+	optional := [][2]string{
+		{"sslrootcert", c.SSLRootCert},
+		{"sslcert", c.SSLCert},
+		{"sslkey", c.SSLKey},
+		{"application_name", c.ApplicationName},
+		{"search_path", c.SearchPath},
+	}
+
+	if c.ConnectTimeout > 0 {
+		// libpq only accepts whole seconds, and 0 would mean no timeout.
+		seconds := max(int64(math.Ceil(c.ConnectTimeout.Seconds())), 1)
+		optional = append(optional, [2]string{"connect_timeout", strconv.FormatInt(seconds, 10)})
+	}
+
+	if c.StatementTimeout > 0 {
+		optional = append(optional, [2]string{"statement_timeout", strconv.FormatInt(c.StatementTimeout.Milliseconds(), 10)})
+	}
+
+	for _, param := range optional {
+		if param[1] != "" {
+			params = append(params, param)
+		}
+	}
+
+	return params
+}
+
 // BuildConnectionString converts connection options to a format
 // that the database library understands.
 func (db *Database) BuildConnectionString() string {
-	return fmt.Sprintf(
-		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		db.config.Host,
-		db.config.Port,
-		db.config.Username,
-		db.config.Password,
-		db.config.Database,
-		db.config.SSLMode,
-	)
+	c := db.config
+	params := append([][2]string{
+		{"host", c.Host},
+		{"port", strconv.FormatInt(c.Port, 10)},
+		{"user", c.Username},
+		{"password", c.Password},
+		{"dbname", c.Database},
+	}, db.connectionParams()...)
+
+	pairs := make([]string, len(params))
+	for i, param := range params {
+		pairs[i] = param[0] + "=" + quoteConnectionValue(param[1])
+	}
+
+	return strings.Join(pairs, " ")
+}
+
+// quoteConnectionValue quotes a value of a key=value connection string,
+// so values containing spaces or quotes, like passwords, are kept intact.
+func quoteConnectionValue(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
 }
 
 // BuildConnectionURL converts connection options to a format
 // that can be used to connect from CLI to postgres.
 func (db *Database) BuildConnectionURL() string {
-	return fmt.Sprintf(
-		"pgx5://%s:%s@%s:%d/%s?sslmode=%s",
-		db.config.Username,
-		db.config.Password,
-		db.config.Host,
-		db.config.Port,
-		db.config.Database,
-		db.config.SSLMode,
-	)
+	c := db.config
+
+	query := url.Values{}
+	for _, param := range db.connectionParams() {
+		query.Set(param[0], param[1])
+	}
+
+	u := url.URL{
+		Scheme:   "pgx5",
+		User:     url.UserPassword(c.Username, c.Password),
+		Host:     net.JoinHostPort(c.Host, strconv.FormatInt(c.Port, 10)),
+		Path:     "/" + c.Database,
+		RawQuery: query.Encode(),
+	}
+
+	return u.String()
 }
 
 // Connect tries to connect to the database
 // using options that describe the necessary
-// information.
+// information. Read replicas, if any, are
+// connected too.
 func (db *Database) Connect() error {
-	cfg, err := pgx.ParseConfig(db.BuildConnectionString())
+	sqlDB, pool, err := db.open(db.BuildConnectionString())
+	if err != nil {
+		return err
+	}
+
+	db.DB, db.pool = sqlDB, pool
+	return db.connectReplicas()
+}
+
+// open opens a handle to the database of the dsn with the driver and pool
+// settings of the config.
+func (db *Database) open(dsn string) (*sqlx.DB, *pgxpool.Pool, error) {
+	if db.config.Driver == DriverPgxPool {
+		return db.openPool(dsn)
+	}
+
+	cfg, err := pgx.ParseConfig(dsn)
 	if err != nil {
-		return fmt.Errorf("invalid pg dsn: %w", err)
+		return nil, nil, errors.Propagate(err, "invalid pg dsn")
 	}
 
 	sqlDB := stdlib.OpenDB(*cfg)
 
-	db.DB = sqlx.NewDb(sqlDB, "pgx")
-	return nil
+	if pool := db.config.Pool; pool != nil {
+		configurePool(sqlDB, pool)
+	}
+
+	return sqlx.NewDb(sqlDB, "pgx"), nil, nil
+}
+
+// poolSetter is the part of *sql.DB sizing its connection pool.
+type poolSetter interface {
+	SetMaxOpenConns(n int)
+	SetMaxIdleConns(n int)
+	SetConnMaxLifetime(d time.Duration)
+	SetConnMaxIdleTime(d time.Duration)
+}
+
+// configurePool applies the settings of the pool config that are set,
+// leaving the defaults of database/sql for the others.
+func configurePool(handle poolSetter, pool *PoolConfig) {
+	if pool.MaxOpenConns > 0 {
+		handle.SetMaxOpenConns(pool.MaxOpenConns)
+	}
+	if pool.MaxIdleConns > 0 {
+		handle.SetMaxIdleConns(pool.MaxIdleConns)
+	}
+	if pool.ConnMaxLifetime > 0 {
+		handle.SetConnMaxLifetime(pool.ConnMaxLifetime)
+	}
+	if pool.ConnMaxIdleTime > 0 {
+		handle.SetConnMaxIdleTime(pool.ConnMaxIdleTime)
+	}
 }
 
-func (db *Database) BeginContext(ctx context.Context) (ctxTx context.Context, commit, rollback func() error, err error) {
-	ctxTx, commit, rollback, err = BeginContext(ctx, db.DB)
+// openPool connects through a pgxpool, exposing it as a database/sql
+// handle too so every method of Database keeps working.
+func (db *Database) openPool(dsn string) (*sqlx.DB, *pgxpool.Pool, error) {
+	cfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, nil, errors.Propagate(err, "invalid pg dsn")
+	}
+
+	if pool := db.config.Pool; pool != nil {
+		if pool.MaxOpenConns > 0 {
+			cfg.MaxConns = int32(pool.MaxOpenConns)
+		}
+		if pool.MinConns > 0 {
+			cfg.MinConns = int32(pool.MinConns)
+		}
+		if pool.ConnMaxLifetime > 0 {
+			cfg.MaxConnLifetime = pool.ConnMaxLifetime
+		}
+		if pool.ConnMaxIdleTime > 0 {
+			cfg.MaxConnIdleTime = pool.ConnMaxIdleTime
+		}
+		if pool.HealthCheckPeriod > 0 {
+			cfg.HealthCheckPeriod = pool.HealthCheckPeriod
+		}
+	}
+
+	pool, err := pgxpool.NewWithConfig(context.Background(), cfg)
+	if err != nil {
+		return nil, nil, errors.Propagate(err, "failed to create connection pool")
+	}
+
+	return sqlx.NewDb(stdlib.OpenDBFromPool(pool), "pgx"), pool, nil
+}
+
+// Pool returns the native pgx pool when connected with DriverPgxPool, or
+// nil otherwise.
+func (db *Database) Pool() *pgxpool.Pool {
+	return db.pool
+}
+
+// Close closes the database handle and, in DriverPgxPool mode, the pool
+// behind it, along with the replicas.
+func (db *Database) Close() error {
+	if db.DB == nil {
+		return nil
+	}
+
+	db.closeReplicas()
+
+	err := db.DB.Close()
+	if db.pool != nil {
+		db.pool.Close()
+	}
+
+	return err
+}
+
+func (db *Database) BeginContext(ctx context.Context, opts ...TxOption) (ctxTx context.Context, commit, rollback func() error, err error) {
+	ctxTx, commit, rollback, err = BeginContext(ctx, db.DB, opts...)
 	if err != nil {
 		err = errors.Propagate(err, "failed to begin database transaction")
 	}
@@ -90,7 +257,10 @@ func (db *Database) Executor(ctx context.Context) Executor {
 	return db
 }
 
-func (db *Database) Create(ctx context.Context, query string, resource any) error {
+func (db *Database) Create(ctx context.Context, query string, resource any) (err error) {
+	ctx, end := db.instrument(ctx, opCreate, query)
+	defer end(&err)
+
 	ectx := errors.Context(
 		errors.Field("query", query),
 		errors.Field("resource_name", resource),
@@ -100,37 +270,7 @@ func (db *Database) Create(ctx context.Context, query string, resource any) erro
 
 	rows, err := executor.NamedQuery(query, resource)
 	if err != nil {
-		if pgErr, ok := err.(*pgconn.PgError); ok {
-			switch pgErr.Code {
-			case "23505": // UNIQUE violation
-				// @TODO include the unique parameter violated in the error user scope.
-				return errors.PropagateAs(
-					errors.KindUserError,
-					err,
-					"resource already exists",
-					errors.Hint(
-						"Another resource with similar parameters already exists in our system. "+
-							"Please change the parameters and try again.",
-					),
-					ectx,
-				)
-			case "23502": // NOT NULL constraint violation
-				return errors.PropagateAs(
-					errors.KindUserError,
-					err,
-					"missing required field",
-					errors.Hint(
-						"You're trying to create a new resource but some parameters are missing. "+
-							"Please, check the documentation to clarify which parameters are necessary and try again.",
-					),
-					ectx,
-				)
-			default:
-				return errors.PropagateAs(errors.KindSystemError, err, "missing required field", ectx)
-			}
-		}
-
-		return errors.PropagateAs(errors.KindSystemError, err, "failed to insert resource", ectx)
+		return TranslateError(errors.KindSystemError, err, "failed to insert resource", ectx)
 	}
 	defer rows.Close()
 
@@ -139,26 +279,39 @@ func (db *Database) Create(ctx context.Context, query string, resource any) erro
 			return errors.PropagateAs(errors.KindSystemError, err, "failed to scan returned resource", ectx)
 		}
 	} else {
-		return errors.PropagateAs(errors.KindSystemError, err, "no rows returned while scanning resource during creation", ectx)
+		// Constraint violations usually surface once rows are read.
+		if err := rows.Err(); err != nil {
+			return TranslateError(errors.KindSystemError, err, "failed to insert resource", ectx)
+		}
+
+		return errors.New(errors.KindSystemError, "no rows returned while scanning resource during creation", ectx)
 	}
 
 	return nil
 }
 
-func (db *Database) List(ctx context.Context, query string, resourceList any, args ...any) error {
+func (db *Database) List(ctx context.Context, query string, resourceList any, args ...any) (err error) {
+	ctx, end := db.instrument(ctx, opList, query)
+	defer end(&err)
+
 	ectx := errors.Context(
 		errors.Field("query", query),
 	)
 
-	executor := db.Executor(ctx)
-	if err := executor.Select(resourceList, query, args...); err != nil {
-		return errors.PropagateAs(errors.KindSystemError, err, "failed to select resources", ectx)
+	err = db.read(ctx, func(executor Executor) error {
+		return executor.Select(resourceList, query, args...)
+	})
+	if err != nil {
+		return TranslateError(errors.KindSystemError, err, "failed to select resources", ectx)
 	}
 
 	return nil
 }
 
-func (db *Database) Delete(ctx context.Context, query string, args ...any) error {
+func (db *Database) Delete(ctx context.Context, query string, args ...any) (err error) {
+	ctx, end := db.instrument(ctx, opDelete, query)
+	defer end(&err)
+
 	ectx := errors.Context(
 		errors.Field("query", query),
 		errors.Field("args", args),
@@ -167,7 +320,7 @@ func (db *Database) Delete(ctx context.Context, query string, args ...any) error
 	executor := db.Executor(ctx)
 	res, err := executor.Exec(query, args...)
 	if err != nil {
-		return errors.PropagateAs(errors.KindSystemError, err, "failed to execute delete query", ectx)
+		return TranslateError(errors.KindSystemError, err, "failed to execute delete query", ectx)
 	}
 
 	rows, err := res.RowsAffected()
@@ -187,7 +340,13 @@ func (db *Database) Delete(ctx context.Context, query string, args ...any) error
 	return nil
 }
 
-func (db *Database) Update(ctx context.Context, query string, args ...any) error {
+// Update executes the query and fails with KindNotFoundError when no row
+// was affected, or KindDatabaseOptimisticLockError when the query is
+// guarded by a Version argument.
+func (db *Database) Update(ctx context.Context, query string, args ...any) (err error) {
+	ctx, end := db.instrument(ctx, opUpdate, query)
+	defer end(&err)
+
 	ectx := errors.Context(
 		errors.Field("query", query),
 		errors.Field("args", args),
@@ -196,7 +355,7 @@ func (db *Database) Update(ctx context.Context, query string, args ...any) error
 	executor := db.Executor(ctx)
 	res, err := executor.Exec(query, args...)
 	if err != nil {
-		return errors.PropagateAs(errors.KindSystemError, err, "failed to execute query while updating resource", ectx)
+		return TranslateError(errors.KindSystemError, err, "failed to execute query while updating resource", ectx)
 	}
 
 	rows, err := res.RowsAffected()
@@ -204,6 +363,10 @@ func (db *Database) Update(ctx context.Context, query string, args ...any) error
 		return errors.PropagateAs(errors.KindSystemError, err, "failed to get affected rows while updating resource", ectx)
 	}
 
+	if version, ok := versioned(args); ok && rows < 1 {
+		return optimisticLockError(version, ectx)
+	}
+
 	if rows < 1 {
 		return errors.New(
 			errors.KindNotFoundError,
@@ -216,14 +379,48 @@ func (db *Database) Update(ctx context.Context, query string, args ...any) error
 	return nil
 }
 
-func (db *Database) Read(ctx context.Context, query string, resource any, args ...any) error {
+func (db *Database) Read(ctx context.Context, query string, resource any, args ...any) (err error) {
+	ctx, end := db.instrument(ctx, opRead, query)
+	defer end(&err)
+
 	ectx := errors.Context(
 		errors.Field("query", query),
 		errors.Field("args", args),
 	)
 
-	executor := db.Executor(ctx)
-	err := executor.Get(resource, query, args...)
+	err = db.read(ctx, func(executor Executor) error {
+		return executor.Get(resource, query, args...)
+	})
+
+	return getError(err, args, "failed to read dataset from database", ectx)
+}
+
+// updateReturning executes an update returning the changed row, scanning
+// it into resource. Being a write, it runs on the primary, or in the
+// transaction of the context, and fails like Read when no row is returned.
+func (db *Database) updateReturning(ctx context.Context, query string, resource any, args ...any) (err error) {
+	ctx, end := db.instrument(ctx, opUpdate, query)
+	defer end(&err)
+
+	ectx := errors.Context(
+		errors.Field("query", query),
+		errors.Field("args", args),
+	)
+
+	err = db.Executor(ctx).Get(resource, query, args...)
+
+	return getError(err, args, "failed to execute query while updating resource", ectx)
+}
+
+// getError translates the error of a query scanning a single row, which
+// fails with KindNotFoundError when there's no row, or with
+// KindDatabaseOptimisticLockError when the query is guarded by a Version
+// argument.
+func getError(err error, args []any, message string, ectx errors.Opt) error {
+	if version, ok := versioned(args); ok && errors.Is(err, sql.ErrNoRows) {
+		return optimisticLockError(version, ectx)
+	}
+
 	if errors.Is(err, sql.ErrNoRows) {
 		return errors.New(
 			errors.KindNotFoundError,
@@ -234,13 +431,16 @@ func (db *Database) Read(ctx context.Context, query string, resource any, args .
 	}
 
 	if err != nil {
-		return errors.PropagateAs(errors.KindSystemError, err, "failed to read dataset from database", ectx)
+		return TranslateError(errors.KindSystemError, err, message, ectx)
 	}
 
 	return nil
 }
 
-func (db *Database) RawExec(ctx context.Context, query string, args ...any) (sql.Result, error) {
+func (db *Database) RawExec(ctx context.Context, query string, args ...any) (_ sql.Result, err error) {
+	ctx, end := db.instrument(ctx, opExec, query)
+	defer end(&err)
+
 	ectx := errors.Context(
 		errors.Field("query", query),
 		errors.Field("args", args),
@@ -249,13 +449,16 @@ func (db *Database) RawExec(ctx context.Context, query string, args ...any) (sql
 	executor := db.Executor(ctx)
 	res, err := executor.Exec(query, args...)
 	if err != nil {
-		return nil, errors.PropagateAs(errors.KindSystemError, err, "failed to execute arbitrary query", ectx)
+		return nil, TranslateError(errors.KindSystemError, err, "failed to execute arbitrary query", ectx)
 	}
 
 	return res, nil
 }
 
-func (db *Database) NamedRawExec(ctx context.Context, query string, resource any) (sql.Result, error) {
+func (db *Database) NamedRawExec(ctx context.Context, query string, resource any) (_ sql.Result, err error) {
+	ctx, end := db.instrument(ctx, opExec, query)
+	defer end(&err)
+
 	ectx := errors.Context(
 		errors.Field("query", query),
 		errors.Field("arg", resource),
@@ -264,7 +467,7 @@ func (db *Database) NamedRawExec(ctx context.Context, query string, resource any
 	executor := db.Executor(ctx)
 	res, err := executor.NamedExec(query, resource)
 	if err != nil {
-		return nil, errors.PropagateAs(errors.KindSystemError, err, "failed to execute arbitrary named query", ectx)
+		return nil, TranslateError(errors.KindSystemError, err, "failed to execute arbitrary named query", ectx)
 	}
 
 	return res, nil
@@ -5,4 +5,16 @@ import "github.com/dexlabsio/garlic/errors"
 var (
 	KindDatabaseRecordNotFoundError = errors.Get("DatabaseRecordNotFoundError")
 	KindDatabaseTransactionError    = errors.Get("DatabaseTransactionError")
+
+	KindDatabaseUniqueViolationError     = errors.Get("DatabaseUniqueViolationError")
+	KindDatabaseForeignKeyViolationError = errors.Get("DatabaseForeignKeyViolationError")
+	KindDatabaseExclusionViolationError  = errors.Get("DatabaseExclusionViolationError")
+	KindDatabaseCheckViolationError      = errors.Get("DatabaseCheckViolationError")
+	KindDatabaseNotNullViolationError    = errors.Get("DatabaseNotNullViolationError")
+	KindDatabaseOptimisticLockError      = errors.Get("DatabaseOptimisticLockError")
+	KindDatabaseSerializationError       = errors.Get("DatabaseSerializationError")
+	KindDatabaseDeadlockError            = errors.Get("DatabaseDeadlockError")
+	KindDatabaseLockTimeoutError         = errors.Get("DatabaseLockTimeoutError")
+	KindDatabaseQueryCanceledError       = errors.Get("DatabaseQueryCanceledError")
+	KindDatabaseConnectionError          = errors.Get("DatabaseConnectionError")
 )
@@ -2,34 +2,183 @@ package database
 
 import (
 	"fmt"
-	"maps"
 	"reflect"
-	"slices"
+	"regexp"
+	"strconv"
+	"strings"
 )
 
+// Operator is the comparison applied by a filter.
+type Operator string
+
+const (
+	OpEq     Operator = "eq"
+	OpNe     Operator = "ne"
+	OpLike   Operator = "like"
+	OpIlike  Operator = "ilike"
+	OpIn     Operator = "in"
+	OpGt     Operator = "gt"
+	OpGte    Operator = "gte"
+	OpLt     Operator = "lt"
+	OpLte    Operator = "lte"
+	OpIsNull Operator = "is_null"
+	OpRange  Operator = "range"
+)
+
+var (
+	comparisons = map[Operator]string{
+		OpEq:    "=",
+		OpNe:    "<>",
+		OpLike:  "LIKE",
+		OpIlike: "ILIKE",
+		OpGt:    ">",
+		OpGte:   ">=",
+		OpLt:    "<",
+		OpLte:   "<=",
+	}
+
+	identifierReg = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*(\.[a-zA-Z_][a-zA-Z0-9_]*)?$`)
+	boundedType   = reflect.TypeOf((*bounded)(nil)).Elem()
+)
+
+// Range filters values between two optional bounds, both inclusive.
+type Range[T any] struct {
+	From *T `json:"from,omitempty"`
+	To   *T `json:"to,omitempty"`
+}
+
+func (r Range[T]) bounds() (from, to any) {
+	if r.From != nil {
+		from = *r.From
+	}
+	if r.To != nil {
+		to = *r.To
+	}
+	return
+}
+
+type bounded interface {
+	bounds() (from, to any)
+}
+
 type Filter struct {
 	key   string
+	op    Operator
 	value any
 }
 
+func (f *Filter) Key() string {
+	return f.key
+}
+
+func (f *Filter) Operator() Operator {
+	return f.op
+}
+
+func (f *Filter) Value() any {
+	return f.value
+}
+
+// Statement formats the filter as an equality with the value inlined.
+//
+// Deprecated: values are not parameterized and the operator is ignored.
+// Use Filters.Where or Filters.Apply instead.
 func (f *Filter) Statement() string {
-	return fmt.Sprintf("%s='%s'", f.key, f.value)
+	return fmt.Sprintf("%s='%s'", f.key, strings.ReplaceAll(fmt.Sprint(f.value), "'", "''"))
+}
+
+// condition compiles the filter into a SQL condition, appending its
+// arguments to args. Placeholders are numbered after the existing args.
+func (f *Filter) condition(args []any) (string, []any) {
+	placeholder := func(value any) string {
+		args = append(args, value)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	switch f.op {
+	case OpIsNull:
+		if isNull, _ := f.value.(bool); isNull {
+			return f.key + " IS NULL", args
+		}
+		return f.key + " IS NOT NULL", args
+
+	case OpIn:
+		return f.key + " = ANY(" + placeholder(f.value) + ")", args
+
+	case OpRange:
+		from, to := f.value.(bounded).bounds()
+		conditions := []string{}
+		if from != nil {
+			conditions = append(conditions, f.key+" >= "+placeholder(from))
+		}
+		if to != nil {
+			conditions = append(conditions, f.key+" <= "+placeholder(to))
+		}
+		return strings.Join(conditions, " AND "), args
+	}
+
+	return f.key + " " + comparisons[f.op] + " " + placeholder(f.value), args
+}
+
+// Filters are compiled in the order of the struct fields they were
+// extracted from, so the generated queries are stable.
+type Filters []*Filter
+
+// Conditions compiles the filters into conditions joined by AND. args are
+// the arguments already bound by the query; the placeholders of the
+// filters are numbered after them and the returned arguments include them.
+// It returns an empty string when there is no filter.
+func (fs Filters) Conditions(args ...any) (string, []any) {
+	conditions := make([]string, 0, len(fs))
+	for _, f := range fs {
+		var condition string
+		condition, args = f.condition(args)
+		if condition != "" {
+			conditions = append(conditions, condition)
+		}
+	}
+
+	return strings.Join(conditions, " AND "), args
 }
 
-// ExtractFilters inspects any struct (or pointer to a struct) and returns a map
-// where the keys are the values of the "filter" tag and the values are the underlying
-// values pointed to by the field. For each field with the tag:
-//   - If the field is not a pointer, the function panics.
-//   - If the pointer is nil, the field is skipped.
+// Where is like Conditions but returns a WHERE clause, prefixed with a
+// space, or an empty string when there is no filter.
+func (fs Filters) Where(args ...any) (string, []any) {
+	conditions, args := fs.Conditions(args...)
+	if conditions == "" {
+		return "", args
+	}
+
+	return " WHERE " + conditions, args
+}
+
+// Apply appends the WHERE clause of the filters to query, which must not
+// have one already. The result can be handed directly to Database.List:
+//
+//	query, args := filters.Apply("SELECT * FROM users")
+//	err := db.List(ctx, query, &users, args...)
+func (fs Filters) Apply(query string, args ...any) (string, []any) {
+	where, args := fs.Where(args...)
+	return query + where, args
+}
+
+// ExtractFilters inspects any struct (or pointer to a struct) and returns
+// the filters declared by its fields through the "filter" tag, which holds
+// the column name and optionally an operator, as in `filter:"name,op=ilike"`.
+// The default operator is eq, in for slices and range for Range fields.
+// For each field with the tag:
+//   - If the field is not a pointer or a slice, the function panics.
+//   - If the column name or operator is invalid, the function panics.
+//   - If the pointer or slice is nil, the field is skipped.
 //   - If the field has no "filter" tag, it is skipped.
-func ExtractFilters(input interface{}) []*Filter {
+func ExtractFilters(input interface{}) Filters {
 	// Use reflection to obtain the input’s value.
 	val := reflect.ValueOf(input)
 
 	// If the input is a pointer, make sure it isn't nil and then dereference it.
 	if val.Kind() == reflect.Ptr {
 		if val.IsNil() {
-			return []*Filter{}
+			return Filters{}
 		}
 		val = val.Elem()
 	}
@@ -39,8 +188,7 @@ func ExtractFilters(input interface{}) []*Filter {
 		panic("input must be a struct or a pointer to a struct")
 	}
 
-	// Prepare a map to hold the filters.
-	uniqueFilters := map[string]*Filter{}
+	filters := Filters{}
 	typ := val.Type()
 
 	// Iterate over all fields in the struct.
@@ -54,25 +202,72 @@ func ExtractFilters(input interface{}) []*Filter {
 
 		fieldValue := val.Field(i)
 
-		// Enforce that the field must be a pointer.
-		if fieldValue.Kind() != reflect.Ptr {
-			panic(fmt.Sprintf("field %q is tagged with filter but is not a pointer", field.Name))
+		// Enforce that the field must be a pointer or a slice, so unset
+		// filters can be told apart from zero values.
+		if fieldValue.Kind() != reflect.Ptr && fieldValue.Kind() != reflect.Slice {
+			panic(fmt.Sprintf("field %q is tagged with filter but is not a pointer or a slice", field.Name))
 		}
 
+		key, op := parseFilterTag(field, fieldValue.Type())
+
 		// If the pointer is nil, skip this field.
 		if fieldValue.IsNil() {
 			continue
 		}
 
-		filter := &Filter{
-			key:   filterTag,
-			value: fieldValue.Elem().Interface(),
+		value := fieldValue.Interface()
+		if fieldValue.Kind() == reflect.Ptr {
+			value = fieldValue.Elem().Interface()
 		}
 
-		// Dereference the pointer to get the actual value and add it to the map.
-		uniqueFilters[filterTag] = filter
+		filters = append(filters, &Filter{key: key, op: op, value: value})
 	}
 
-	filters := slices.Collect(maps.Values(uniqueFilters))
 	return filters
 }
+
+// parseFilterTag reads the column and the operator of a filter tag,
+// panicking on invalid declarations since they're programming errors.
+func parseFilterTag(field reflect.StructField, t reflect.Type) (string, Operator) {
+	key, options, _ := strings.Cut(field.Tag.Get("filter"), ",")
+	if !identifierReg.MatchString(key) {
+		panic(fmt.Sprintf("field %q has an invalid filter column %q", field.Name, key))
+	}
+
+	elem := t
+	if elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+
+	op := OpEq
+	switch {
+	case elem.Implements(boundedType):
+		op = OpRange
+	case elem.Kind() == reflect.Slice && elem.Elem().Kind() != reflect.Uint8:
+		op = OpIn
+	}
+
+	for _, option := range strings.Split(options, ",") {
+		if name, value, _ := strings.Cut(option, "="); name == "op" {
+			op = Operator(value)
+		}
+	}
+
+	var valid bool
+	switch op {
+	case OpIn:
+		valid = elem.Kind() == reflect.Slice || elem.Kind() == reflect.Array
+	case OpIsNull:
+		valid = elem.Kind() == reflect.Bool
+	case OpRange:
+		valid = elem.Implements(boundedType)
+	default:
+		_, valid = comparisons[op]
+	}
+
+	if !valid {
+		panic(fmt.Sprintf("field %q has an invalid filter operator %q for type %s", field.Name, op, t))
+	}
+
+	return key, op
+}
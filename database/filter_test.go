@@ -0,0 +1,72 @@
+//go:build unit
+// +build unit
+
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type userFilters struct {
+	Name      *string           `filter:"name,op=ilike"`
+	Email     *string           `filter:"email"`
+	Roles     []string          `filter:"role"`
+	Deleted   *bool             `filter:"deleted_at,op=is_null"`
+	Age       *int              `filter:"u.age,op=gte"`
+	CreatedAt *Range[time.Time] `filter:"created_at"`
+	Ignored   string
+}
+
+func TestFiltersWhere(t *testing.T) {
+	name := "%o'brien%"
+	deleted := true
+	age := 18
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	filters := ExtractFilters(&userFilters{
+		Name:      &name,
+		Roles:     []string{"admin", "owner"},
+		Deleted:   &deleted,
+		Age:       &age,
+		CreatedAt: &Range[time.Time]{From: &from},
+	})
+
+	query, args := filters.Apply("SELECT * FROM users")
+	assert.Equal(
+		t,
+		"SELECT * FROM users WHERE name ILIKE $1 AND role = ANY($2) AND deleted_at IS NULL AND u.age >= $3 AND created_at >= $4",
+		query,
+	)
+	assert.Equal(t, []any{name, []string{"admin", "owner"}, age, from}, args)
+
+	conditions, args := filters.Conditions("org")
+	assert.Equal(t, "name ILIKE $2 AND role = ANY($3) AND deleted_at IS NULL AND u.age >= $4 AND created_at >= $5", conditions)
+	assert.Equal(t, []any{"org", name, []string{"admin", "owner"}, age, from}, args)
+
+	where, args := ExtractFilters(userFilters{}).Where()
+	assert.Empty(t, where)
+	assert.Empty(t, args)
+}
+
+func TestInvalidFilters(t *testing.T) {
+	assert.Panics(t, func() {
+		ExtractFilters(struct {
+			Name *string `filter:"name; DROP TABLE users"`
+		}{})
+	})
+
+	assert.Panics(t, func() {
+		ExtractFilters(struct {
+			Name *string `filter:"name,op=in"`
+		}{})
+	})
+
+	assert.Panics(t, func() {
+		ExtractFilters(struct {
+			Name string `filter:"name"`
+		}{})
+	})
+}
@@ -0,0 +1,118 @@
+package database
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/dexlabsio/garlic/errors"
+	"github.com/dexlabsio/garlic/logging"
+	"github.com/dexlabsio/garlic/monitoring"
+	"github.com/dexlabsio/garlic/tracing"
+)
+
+// Operations of the Store, labelling the query metrics and spans.
+const (
+	opCreate     = "create"
+	opCreateMany = "create_many"
+	opUpsert     = "upsert"
+	opCopy       = "copy"
+	opRead       = "read"
+	opList       = "list"
+	opListPage   = "list_page"
+	opUpdate     = "update"
+	opDelete     = "delete"
+	opExec       = "exec"
+)
+
+// UnnamedQuery is the name of queries named neither by the context nor by
+// a comment.
+const UnnamedQuery = "unnamed"
+
+// queryNameReg matches a leading comment naming the query, such as
+// `-- name: users.by_email`.
+var queryNameReg = regexp.MustCompile(`^\s*--\s*name:\s*([\w.:-]+)`)
+
+// WithQueryName names the queries run with the context, labelling their
+// metrics, spans and slow query logs. Names should be few and stable, like
+// the name of a repository method, since each one is a metric series.
+func WithQueryName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, QueryNameKey, name)
+}
+
+// QueryName returns the name of the query, set in the context with
+// WithQueryName or, else, by a leading `-- name: ...` comment of the query.
+func QueryName(ctx context.Context, query string) string {
+	if name, ok := ctx.Value(QueryNameKey).(string); ok && name != "" {
+		return name
+	}
+
+	if match := queryNameReg.FindStringSubmatch(query); match != nil {
+		return match[1]
+	}
+
+	return UnnamedQuery
+}
+
+// withDefaultQueryName names the queries of the context, unless the caller
+// already named them.
+func withDefaultQueryName(ctx context.Context, name string) context.Context {
+	if _, ok := ctx.Value(QueryNameKey).(string); ok {
+		return ctx
+	}
+
+	return WithQueryName(ctx, name)
+}
+
+// instrument measures an operation of the Store, starting its span and
+// returning the context carrying it, which the operation runs with so the
+// spans it starts are children of it, and the function that ends it with
+// the error of the operation:
+//
+//	func (db *Database) Read(...) (err error) {
+//		ctx, end := db.instrument(ctx, opRead, query)
+//		defer end(&err)
+//
+// Operations slower than the SlowQueryThreshold of the config are logged
+// with the logger of the context.
+func (db *Database) instrument(ctx context.Context, operation, query string) (context.Context, func(*error)) {
+	name := QueryName(ctx, query)
+	start := time.Now()
+
+	spanCtx, span := tracing.StartSpan(ctx, "db."+operation, map[string]string{
+		"db.system":     "postgresql",
+		"db.operation":  operation,
+		"db.query.name": name,
+		"db.statement":  query,
+	})
+
+	return spanCtx, func(errp *error) {
+		elapsed := time.Since(start)
+
+		status := "ok"
+		if err := *errp; err != nil {
+			status = "error"
+			if e, ok := err.(*errors.ErrorT); ok && e.Kind() != nil {
+				status = e.Kind().Name
+			}
+		}
+
+		monitoring.ObserveQuery(operation, name, status, elapsed.Seconds())
+		span.End(*errp)
+
+		if db.config == nil || db.config.SlowQueryThreshold <= 0 || elapsed < db.config.SlowQueryThreshold {
+			return
+		}
+
+		logging.GetLoggerFromContextOrGlobal(ctx).Warn(
+			"Slow database query",
+			zap.String("operation", operation),
+			zap.String("name", name),
+			zap.String("status", status),
+			zap.Duration("duration", elapsed),
+			zap.String("query", query),
+		)
+	}
+}
@@ -0,0 +1,112 @@
+//go:build unit
+// +build unit
+
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/dexlabsio/garlic/logging"
+	"github.com/dexlabsio/garlic/monitoring"
+	"github.com/dexlabsio/garlic/tracing"
+)
+
+// spanKey carries the name of the current span in the contexts of the
+// spanRecorder.
+type spanKey struct{}
+
+type spanRecorder struct {
+	names   []string
+	parents []string
+	errors  []error
+}
+
+func (s *spanRecorder) Start(ctx context.Context, name string, attributes map[string]string) (context.Context, tracing.Span) {
+	name += " " + attributes["db.query.name"]
+	parent, _ := ctx.Value(spanKey{}).(string)
+
+	s.names = append(s.names, name)
+	s.parents = append(s.parents, parent)
+	return context.WithValue(ctx, spanKey{}, name), s
+}
+
+func (s *spanRecorder) End(err error) {
+	s.errors = append(s.errors, err)
+}
+
+func TestQueryName(t *testing.T) {
+	ctx := context.Background()
+	query := "-- name: users.by_email\nSELECT * FROM users WHERE email = $1"
+
+	assert.Equal(t, "users.by_email", QueryName(ctx, query))
+	assert.Equal(t, "users.search", QueryName(WithQueryName(ctx, "users.search"), query))
+	assert.Equal(t, UnnamedQuery, QueryName(ctx, "SELECT 1"))
+	assert.Equal(t, "users.search", QueryName(withDefaultQueryName(WithQueryName(ctx, "users.search"), "users.get"), query))
+}
+
+func TestInstrumentation(t *testing.T) {
+	rec, sqlDB := newRecorder()
+	config := Defaults()
+	config.SlowQueryThreshold = time.Nanosecond
+	db := &Database{config: config, DB: sqlDB}
+
+	core, logs := observer.New(zap.WarnLevel)
+	ctx := logging.SetContextLogger(context.Background(), zap.New(core))
+
+	tracer := &spanRecorder{}
+	tracing.SetTracer(tracer)
+	defer tracing.SetTracer(nil)
+
+	before := observations(opExec, "users.touch", "ok")
+
+	_, err := db.RawExec(ctx, "-- name: users.touch\nUPDATE users SET seen_at = now()")
+	assert.NoError(t, err)
+
+	rec.fail = func(string) error { return &pgconn.PgError{Code: "23505"} }
+	_, err = db.RawExec(ctx, "INSERT INTO users DEFAULT VALUES")
+	assert.Error(t, err)
+
+	assert.Equal(t, []string{"db.exec users.touch", "db.exec unnamed"}, tracer.names)
+	assert.Nil(t, tracer.errors[0])
+	assert.Equal(t, err, tracer.errors[1])
+
+	entries := logs.FilterMessage("Slow database query").All()
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "users.touch", entries[0].ContextMap()["name"])
+	assert.Equal(t, "DatabaseUniqueViolationError", entries[1].ContextMap()["status"])
+
+	assert.Equal(t, before+1, observations(opExec, "users.touch", "ok"))
+}
+
+func TestInstrumentationParentsNestedSpans(t *testing.T) {
+	db := &Database{config: Defaults()}
+
+	tracer := &spanRecorder{}
+	tracing.SetTracer(tracer)
+	defer tracing.SetTracer(nil)
+
+	ctx, endOuter := db.instrument(context.Background(), opCreateMany, "-- name: users.import\nINSERT INTO users")
+	_, endInner := db.instrument(ctx, opExec, "-- name: users.touch\nUPDATE users SET seen_at = now()")
+
+	var err error
+	endInner(&err)
+	endOuter(&err)
+
+	assert.Equal(t, []string{"db.create_many users.import", "db.exec users.touch"}, tracer.names)
+	assert.Equal(t, []string{"", "db.create_many users.import"}, tracer.parents)
+}
+
+func observations(labels ...string) uint64 {
+	metric := &dto.Metric{}
+	_ = monitoring.QueryMetric().WithLabelValues(labels...).(prometheus.Metric).Write(metric)
+	return metric.GetHistogram().GetSampleCount()
+}
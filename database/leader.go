@@ -0,0 +1,80 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/dexlabsio/garlic/errors"
+	"github.com/dexlabsio/garlic/logging"
+)
+
+// Lead runs fn only while this replica is the leader of the name, elected
+// by holding the lock of the name. Replicas that aren't elected try again
+// every interval, so one of them takes over when the leader stops.
+//
+// The context of fn is cancelled when the leadership is lost, such as when
+// the connection holding the lock is lost, and fn must then return
+// promptly, since another replica may be elected right away. Lead then
+// campaigns again. When fn returns on its own, the leadership is given up
+// and Lead returns its error. Lead returns nil once ctx is done.
+func (db *Database) Lead(ctx context.Context, name string, interval time.Duration, fn func(context.Context) error) error {
+	l := logging.GetLoggerFromContextOrGlobal(ctx).With(zap.String("leadership", name))
+
+	for {
+		lock, err := db.TryLock(ctx, name)
+		if err != nil && ctx.Err() == nil {
+			l.Warn("Failed to campaign for leadership", errors.Zap(err))
+		}
+
+		if lock != nil {
+			l.Info("Elected leader")
+
+			lost, err := lead(ctx, lock, fn)
+			if err := lock.Unlock(); err != nil && !lost {
+				l.Error("Failed to give up leadership", errors.Zap(err))
+			}
+
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			if !lost {
+				return err
+			}
+
+			l.Warn("Lost leadership", errors.Zap(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// lead runs fn until it returns, cancelling its context if the lock is
+// lost meanwhile, and reports whether it was.
+func lead(ctx context.Context, lock *Lock, fn func(context.Context) error) (bool, error) {
+	leaderCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		select {
+		case <-lock.Lost():
+			cancel()
+		case <-leaderCtx.Done():
+		}
+	}()
+
+	err := fn(leaderCtx)
+
+	select {
+	case <-lock.Lost():
+		return true, err
+	default:
+		return false, err
+	}
+}
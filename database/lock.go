@@ -0,0 +1,241 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/dexlabsio/garlic/errors"
+)
+
+// LockKey hashes the name of a lock into the key of a postgres advisory
+// lock. Names should be namespaced by their use, as in
+// "billing:invoices:close", since every service sharing the database
+// shares the keys.
+func LockKey(name string) int64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(name))
+	return int64(hash.Sum64())
+}
+
+// Lock is a session advisory lock, held by a dedicated connection until
+// it's unlocked or the connection is lost.
+type Lock struct {
+	name string
+	key  int64
+	conn *sqlx.Conn
+
+	lost   chan struct{}
+	stop   context.CancelFunc
+	unlock sync.Once
+	err    error
+}
+
+// TryLock acquires the lock of the name if it's free, without waiting. It
+// returns a nil lock, and no error, when the lock is held by someone else.
+func (db *Database) TryLock(ctx context.Context, name string) (*Lock, error) {
+	ectx := errors.Context(
+		errors.Field("lock", name),
+	)
+
+	conn, err := db.Connx(ctx)
+	if err != nil {
+		return nil, TranslateError(errors.KindSystemError, err, "failed to acquire connection for lock", ectx)
+	}
+
+	key := LockKey(name)
+
+	var acquired bool
+	if err := conn.GetContext(ctx, &acquired, "SELECT pg_try_advisory_lock($1)", key); err != nil {
+		_ = conn.Close()
+		return nil, TranslateError(errors.KindSystemError, err, "failed to try lock", ectx)
+	}
+
+	if !acquired {
+		_ = conn.Close()
+		return nil, nil
+	}
+
+	return db.newLock(name, key, conn), nil
+}
+
+// Lock acquires the lock of the name, waiting until it's free for up to
+// the timeout, or as long as the context allows with no timeout. It fails
+// with KindDatabaseLockTimeoutError when the timeout is reached.
+func (db *Database) Lock(ctx context.Context, name string, timeout time.Duration) (*Lock, error) {
+	ectx := errors.Context(
+		errors.Field("lock", name),
+		errors.Field("timeout", timeout),
+	)
+
+	conn, err := db.Connx(ctx)
+	if err != nil {
+		return nil, TranslateError(errors.KindSystemError, err, "failed to acquire connection for lock", ectx)
+	}
+
+	lockCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		lockCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	key := LockKey(name)
+	if _, err := conn.ExecContext(lockCtx, "SELECT pg_advisory_lock($1)", key); err != nil {
+		_ = conn.Close()
+
+		if lockCtx.Err() != nil && ctx.Err() == nil {
+			return nil, errors.PropagateAs(
+				KindDatabaseLockTimeoutError,
+				err,
+				"timeout waiting for lock",
+				errors.Hint("Someone else is holding the lock for longer than expected."),
+				ectx,
+			)
+		}
+
+		return nil, TranslateError(errors.KindSystemError, err, "failed to lock", ectx)
+	}
+
+	return db.newLock(name, key, conn), nil
+}
+
+// newLock starts watching the connection holding the lock, so its loss is
+// noticed within the LockCheckPeriod of the config.
+func (db *Database) newLock(name string, key int64, conn *sqlx.Conn) *Lock {
+	ctx, stop := context.WithCancel(context.Background())
+	l := &Lock{
+		name: name,
+		key:  key,
+		conn: conn,
+		lost: make(chan struct{}),
+		stop: stop,
+	}
+
+	period := DefaultLockCheckPeriod
+	if db.config != nil && db.config.LockCheckPeriod > 0 {
+		period = db.config.LockCheckPeriod
+	}
+
+	go l.watch(ctx, period)
+	return l
+}
+
+func (l *Lock) watch(ctx context.Context, period time.Duration) {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// Pings wait for the statements running on the connection, so they
+		// aren't bounded by a timeout, which would report a lock held by a
+		// long statement as lost.
+		if err := l.conn.PingContext(ctx); err != nil && ctx.Err() == nil {
+			close(l.lost)
+			return
+		}
+	}
+}
+
+// Name returns the name of the lock.
+func (l *Lock) Name() string {
+	return l.name
+}
+
+// Conn returns the connection holding the lock, to run statements in the
+// same session, such as the migrations guarded by the lock.
+func (l *Lock) Conn() *sqlx.Conn {
+	return l.conn
+}
+
+// Lost is closed when the connection holding the lock is lost, which
+// releases the lock, so the work it guards must stop.
+func (l *Lock) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// Unlock releases the lock and the connection holding it. It's safe to
+// call more than once.
+func (l *Lock) Unlock() error {
+	l.unlock.Do(func() {
+		l.stop()
+
+		// A fresh context releases the lock even if the one it was
+		// acquired with was cancelled.
+		_, err := l.conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", l.key)
+		if err != nil {
+			// The connection must not go back to the pool holding the lock.
+			_ = l.conn.Raw(func(any) error { return driver.ErrBadConn })
+			l.err = TranslateError(errors.KindSystemError, err, "failed to unlock", errors.Context(
+				errors.Field("lock", l.name),
+			))
+		}
+
+		_ = l.conn.Close()
+	})
+
+	return l.err
+}
+
+// TryLockTx acquires the lock of the name in the transaction of the
+// context if it's free, without waiting, and reports whether it was
+// acquired. The lock is released when the transaction ends.
+func (db *Database) TryLockTx(ctx context.Context, name string) (bool, error) {
+	ectx := errors.Context(
+		errors.Field("lock", name),
+	)
+
+	tx := Transaction(ctx)
+	if tx == nil {
+		return false, errors.New(errors.KindSystemError, "transaction locks require a transaction", ectx)
+	}
+
+	var acquired bool
+	if err := tx.GetContext(ctx, &acquired, "SELECT pg_try_advisory_xact_lock($1)", LockKey(name)); err != nil {
+		return false, TranslateError(errors.KindSystemError, err, "failed to try transaction lock", ectx)
+	}
+
+	return acquired, nil
+}
+
+// LockTx acquires the lock of the name in the transaction of the context,
+// waiting like Lock. The lock is released when the transaction ends. Since
+// postgres aborts a transaction whose statement is cancelled, a timeout
+// leaves the transaction to be rolled back.
+func (db *Database) LockTx(ctx context.Context, name string, timeout time.Duration) error {
+	ectx := errors.Context(
+		errors.Field("lock", name),
+		errors.Field("timeout", timeout),
+	)
+
+	tx := Transaction(ctx)
+	if tx == nil {
+		return errors.New(errors.KindSystemError, "transaction locks require a transaction", ectx)
+	}
+
+	lockCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		lockCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	if _, err := tx.ExecContext(lockCtx, "SELECT pg_advisory_xact_lock($1)", LockKey(name)); err != nil {
+		if lockCtx.Err() != nil && ctx.Err() == nil {
+			return errors.PropagateAs(KindDatabaseLockTimeoutError, err, "timeout waiting for transaction lock", ectx)
+		}
+
+		return TranslateError(errors.KindSystemError, err, "failed to lock in transaction", ectx)
+	}
+
+	return nil
+}
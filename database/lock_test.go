@@ -0,0 +1,102 @@
+//go:build unit
+// +build unit
+
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dexlabsio/garlic/errors"
+)
+
+func TestTryLock(t *testing.T) {
+	rec, sqlDB := newRecorder()
+	db := &Database{config: Defaults(), DB: sqlDB}
+	ctx := context.Background()
+
+	free := true
+	rec.value = func(string) driver.Value { return free }
+
+	lock, err := db.TryLock(ctx, "jobs:cleanup")
+	assert.NoError(t, err)
+	assert.NotNil(t, lock)
+	assert.NoError(t, lock.Unlock())
+	assert.NoError(t, lock.Unlock())
+
+	free = false
+	lock, err = db.TryLock(ctx, "jobs:cleanup")
+	assert.NoError(t, err)
+	assert.Nil(t, lock)
+
+	assert.Equal(t, []string{
+		"SELECT pg_try_advisory_lock($1)",
+		"SELECT pg_advisory_unlock($1)",
+		"SELECT pg_try_advisory_lock($1)",
+	}, rec.Statements())
+}
+
+func TestLockTxRequiresTransaction(t *testing.T) {
+	db := &Database{config: Defaults()}
+
+	_, err := db.TryLockTx(context.Background(), "jobs:cleanup")
+	assert.True(t, errors.IsKind(err, errors.KindSystemError))
+	assert.True(t, errors.IsKind(db.LockTx(context.Background(), "jobs:cleanup", time.Second), errors.KindSystemError))
+}
+
+func TestLead(t *testing.T) {
+	rec, sqlDB := newRecorder()
+	config := Defaults()
+	config.LockCheckPeriod = time.Millisecond
+	db := &Database{config: config, DB: sqlDB}
+
+	var broken atomic.Bool
+	rec.value = func(string) driver.Value { return true }
+	rec.fail = func(query string) error {
+		if query == "PING" && broken.Load() {
+			return fmt.Errorf("connection lost")
+		}
+		return nil
+	}
+
+	// The first term ends when the connection is lost, the second one by
+	// returning, which ends Lead.
+	terms := 0
+	err := db.Lead(context.Background(), "jobs:cleanup", time.Millisecond, func(ctx context.Context) error {
+		terms++
+		if terms == 1 {
+			broken.Store(true)
+			<-ctx.Done()
+			broken.Store(false)
+			return ctx.Err()
+		}
+
+		return fmt.Errorf("done")
+	})
+
+	assert.EqualError(t, err, "done")
+	assert.Equal(t, 2, terms)
+	assert.Equal(t, 2, strings.Count(strings.Join(rec.Statements(), "\n"), "pg_advisory_unlock"))
+}
+
+func TestLeadStopsWithContext(t *testing.T) {
+	rec, sqlDB := newRecorder()
+	db := &Database{config: Defaults(), DB: sqlDB}
+	rec.value = func(string) driver.Value { return false }
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := db.Lead(ctx, "jobs:cleanup", time.Millisecond, func(ctx context.Context) error {
+		t.Fatal("not elected")
+		return nil
+	})
+	assert.NoError(t, err)
+}
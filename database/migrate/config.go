@@ -0,0 +1,19 @@
+package migrate
+
+import "time"
+
+type Config struct {
+	// Table stores the versions applied to the database.
+	Table string `mapstructure:"table" yaml:"table"`
+
+	// LockTimeout bounds how long a replica waits for another one to
+	// finish migrating. Zero waits indefinitely.
+	LockTimeout time.Duration `mapstructure:"lock_timeout" yaml:"lock_timeout"`
+}
+
+func Defaults() *Config {
+	return &Config{
+		Table:       "schema_migrations",
+		LockTimeout: 5 * time.Minute,
+	}
+}
@@ -0,0 +1,285 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"io/fs"
+	"strings"
+	"time"
+
+	pgx "github.com/jackc/pgx/v5"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"github.com/dexlabsio/garlic/database"
+	"github.com/dexlabsio/garlic/errors"
+	"github.com/dexlabsio/garlic/logging"
+)
+
+// Status describes a migration and whether it's applied to the database.
+type Status struct {
+	Version   uint64     `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+// Migrator applies migrations to a database. Every applied version is
+// recorded in the migrations table, and operations hold a Postgres
+// advisory lock, so replicas starting at the same time migrate only once.
+// Each migration runs in its own transaction.
+type Migrator struct {
+	db         *database.Database
+	config     *Config
+	migrations []*Migration
+	table      string
+	lockName   string
+}
+
+// New loads the migrations from fsys, see Load.
+func New(db *database.Database, fsys fs.FS, config *Config) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, errors.Propagate(err, "failed to load migrations")
+	}
+
+	return &Migrator{
+		db:         db,
+		config:     config,
+		migrations: migrations,
+		table:      pgx.Identifier(strings.Split(config.Table, ".")).Sanitize(),
+		lockName:   "garlic:migrate:" + config.Table,
+	}, nil
+}
+
+// Run applies every pending migration. It's meant to be called at service
+// startup, before serving requests.
+func Run(ctx context.Context, db *database.Database, fsys fs.FS, config *Config) error {
+	m, err := New(db, fsys, config)
+	if err != nil {
+		return err
+	}
+
+	return m.Up(ctx)
+}
+
+// Up applies every pending migration in version order.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.Goto(ctx, m.latest())
+}
+
+// Down rolls back the given number of applied migrations, newest first.
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	return m.locked(ctx, func(conn *sqlx.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		down := []*Migration{}
+		for i := len(m.migrations) - 1; i >= 0 && len(down) < steps; i-- {
+			if _, ok := applied[m.migrations[i].Version]; ok {
+				down = append(down, m.migrations[i])
+			}
+		}
+
+		return m.execute(ctx, conn, nil, down)
+	})
+}
+
+// Goto migrates the database to the given version, rolling back the
+// applied migrations above it and applying the pending ones up to it.
+// Goto(0) rolls back every migration.
+func (m *Migrator) Goto(ctx context.Context, version uint64) error {
+	return m.locked(ctx, func(conn *sqlx.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		up, down := plan(m.migrations, applied, version)
+		return m.execute(ctx, conn, up, down)
+	})
+}
+
+// Status lists every known migration and whether it's applied.
+func (m *Migrator) Status(ctx context.Context) ([]*Status, error) {
+	statuses := []*Status{}
+	err := m.locked(ctx, func(conn *sqlx.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			status := &Status{Version: migration.Version, Name: migration.Name}
+			if appliedAt, ok := applied[migration.Version]; ok {
+				status.Applied = true
+				status.AppliedAt = &appliedAt
+			}
+			statuses = append(statuses, status)
+		}
+
+		return nil
+	})
+
+	return statuses, err
+}
+
+func (m *Migrator) latest() uint64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// plan returns the migrations to apply, in ascending order, and to roll
+// back, in descending order, to reach the target version.
+func plan(migrations []*Migration, applied map[uint64]time.Time, target uint64) (up, down []*Migration) {
+	for i := len(migrations) - 1; i >= 0; i-- {
+		migration := migrations[i]
+		if _, ok := applied[migration.Version]; ok && migration.Version > target {
+			down = append(down, migration)
+		}
+	}
+
+	for _, migration := range migrations {
+		if _, ok := applied[migration.Version]; !ok && migration.Version <= target {
+			up = append(up, migration)
+		}
+	}
+
+	return up, down
+}
+
+// locked runs fn on a dedicated connection holding the migrations lock.
+// Session advisory locks belong to a connection, so the lock, the
+// migrations and the unlock must share the same one.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sqlx.Conn) error) error {
+	lock, err := m.db.Lock(ctx, m.lockName, m.config.LockTimeout)
+	if err != nil {
+		return errors.Propagate(
+			err,
+			"failed to acquire migrations lock",
+			errors.Hint("Another instance may be running migrations for too long."),
+		)
+	}
+
+	defer func() {
+		if err := lock.Unlock(); err != nil {
+			logging.Global().Error("Failed to release migrations lock", errors.Zap(err))
+		}
+	}()
+
+	conn := lock.Conn()
+
+	if _, err := conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS `+m.table+` (
+			version bigint PRIMARY KEY,
+			name text NOT NULL,
+			applied_at timestamptz NOT NULL DEFAULT now()
+		)`,
+	); err != nil {
+		return errors.Propagate(err, "failed to create migrations table", errors.Context(errors.Field("table", m.config.Table)))
+	}
+
+	return fn(conn)
+}
+
+// applied returns the applied versions and when they were applied.
+func (m *Migrator) applied(ctx context.Context, conn *sqlx.Conn) (map[uint64]time.Time, error) {
+	rows := []struct {
+		Version   int64     `db:"version"`
+		AppliedAt time.Time `db:"applied_at"`
+	}{}
+
+	if err := conn.SelectContext(ctx, &rows, "SELECT version, applied_at FROM "+m.table); err != nil {
+		return nil, errors.Propagate(err, "failed to read applied migrations", errors.Context(errors.Field("table", m.config.Table)))
+	}
+
+	applied := make(map[uint64]time.Time, len(rows))
+	for _, row := range rows {
+		applied[uint64(row.Version)] = row.AppliedAt
+	}
+
+	return applied, nil
+}
+
+// execute rolls back and then applies the given migrations, stopping at
+// the first failure.
+func (m *Migrator) execute(ctx context.Context, conn *sqlx.Conn, up, down []*Migration) error {
+	for _, migration := range down {
+		if strings.TrimSpace(migration.Down) == "" {
+			return errors.New(
+				errors.KindSystemError,
+				"migration can't be rolled back",
+				errors.Context(errors.Field("version", migration.Version), errors.Field("name", migration.Name)),
+				errors.Hint("Please add a down file for this migration."),
+			)
+		}
+
+		err := m.apply(ctx, conn, migration, "down", migration.Down, "DELETE FROM "+m.table+" WHERE version = $1", migration.Version)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, migration := range up {
+		err := m.apply(ctx, conn, migration, "up", migration.Up, "INSERT INTO "+m.table+" (version, name) VALUES ($1, $2)", migration.Version, migration.Name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// apply runs the migration script and records it in the same transaction.
+func (m *Migrator) apply(ctx context.Context, conn *sqlx.Conn, migration *Migration, direction, script, record string, args ...any) error {
+	ectx := errors.Context(
+		errors.Field("version", migration.Version),
+		errors.Field("name", migration.Name),
+		errors.Field("direction", direction),
+	)
+
+	start := time.Now()
+	err := inTx(ctx, conn, func(tx *sqlx.Tx) error {
+		// Without arguments the script runs through the simple protocol,
+		// which accepts multiple statements.
+		if _, err := tx.ExecContext(ctx, script); err != nil {
+			return err
+		}
+
+		_, err := tx.ExecContext(ctx, record, args...)
+		return err
+	})
+	if err != nil {
+		return errors.Propagate(err, "failed to apply migration", ectx)
+	}
+
+	logging.Global().Info(
+		"Applied migration",
+		zap.Uint64("version", migration.Version),
+		zap.String("name", migration.Name),
+		zap.String("direction", direction),
+		zap.Duration("duration", time.Since(start)),
+	)
+
+	return nil
+}
+
+func inTx(ctx context.Context, conn *sqlx.Conn, fn func(tx *sqlx.Tx) error) error {
+	tx, err := conn.BeginTxx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		if rerr := tx.Rollback(); rerr != nil {
+			logging.Global().Error("Failed to rollback migration", errors.Zap(rerr))
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
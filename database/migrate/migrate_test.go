@@ -0,0 +1,64 @@
+//go:build unit
+// +build unit
+
+package migrate
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load(fstest.MapFS{
+		"0002_add_email.up.sql":      {Data: []byte("ALTER TABLE users ADD email text;")},
+		"0002_add_email.down.sql":    {Data: []byte("ALTER TABLE users DROP email;")},
+		"0001_create_users.up.sql":   {Data: []byte("CREATE TABLE users (id uuid);")},
+		"0001_create_users.down.sql": {Data: []byte("DROP TABLE users;")},
+		"0010_seed.up.sql":           {Data: []byte("INSERT INTO users VALUES (gen_random_uuid());")},
+		"README.md":                  {Data: []byte("ignored")},
+	})
+	assert.NoError(t, err)
+
+	assert.Len(t, migrations, 3)
+	assert.Equal(t, &Migration{Version: 1, Name: "create_users", Up: "CREATE TABLE users (id uuid);", Down: "DROP TABLE users;"}, migrations[0])
+	assert.Equal(t, uint64(2), migrations[1].Version)
+	assert.Equal(t, uint64(10), migrations[2].Version)
+	assert.Empty(t, migrations[2].Down)
+
+	_, err = Load(fstest.MapFS{"0001_a.down.sql": {Data: []byte("SELECT 1")}})
+	assert.Error(t, err)
+
+	_, err = Load(fstest.MapFS{
+		"0001_a.up.sql": {Data: []byte("SELECT 1")},
+		"0001_b.up.sql": {Data: []byte("SELECT 1")},
+	})
+	assert.Error(t, err)
+}
+
+func TestPlan(t *testing.T) {
+	migrations := []*Migration{{Version: 1}, {Version: 2}, {Version: 3}, {Version: 4}}
+	applied := map[uint64]time.Time{1: time.Now(), 3: time.Now(), 4: time.Now()}
+
+	versions := func(ms []*Migration) []uint64 {
+		vs := []uint64{}
+		for _, m := range ms {
+			vs = append(vs, m.Version)
+		}
+		return vs
+	}
+
+	up, down := plan(migrations, applied, 4)
+	assert.Equal(t, []uint64{2}, versions(up))
+	assert.Empty(t, down)
+
+	up, down = plan(migrations, applied, 2)
+	assert.Equal(t, []uint64{2}, versions(up))
+	assert.Equal(t, []uint64{4, 3}, versions(down))
+
+	up, down = plan(migrations, applied, 0)
+	assert.Empty(t, up)
+	assert.Equal(t, []uint64{4, 3, 1}, versions(down))
+}
@@ -0,0 +1,94 @@
+package migrate
+
+import (
+	"cmp"
+	"io/fs"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"github.com/dexlabsio/garlic/errors"
+)
+
+// fileNameReg matches migration files such as 0001_create_users.up.sql.
+var fileNameReg = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is a versioned change to the database schema.
+type Migration struct {
+	Version uint64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Load reads the migrations at the root of fsys, sorted by version. Each
+// version needs an up file and may have a down file. Other files are
+// ignored, so the migrations can live next to related assets. Use
+// fs.Sub to load migrations from a directory of an embed.FS.
+func Load(fsys fs.FS) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, errors.Propagate(err, "failed to read migrations directory")
+	}
+
+	byVersion := map[uint64]*Migration{}
+	for _, entry := range entries {
+		match := fileNameReg.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		ectx := errors.Context(errors.Field("file", entry.Name()))
+
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			return nil, errors.Propagate(err, "invalid migration version", ectx)
+		}
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, errors.Propagate(err, "failed to read migration file", ectx)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+
+		if migration.Name != match[2] {
+			return nil, errors.New(
+				errors.KindSystemError,
+				"duplicated migration version",
+				errors.Context(
+					errors.Field("version", version),
+					errors.Field("names", []string{migration.Name, match[2]}),
+				),
+			)
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, errors.New(
+				errors.KindSystemError,
+				"migration has no up file",
+				errors.Context(errors.Field("version", migration.Version), errors.Field("name", migration.Name)),
+			)
+		}
+		migrations = append(migrations, migration)
+	}
+
+	slices.SortFunc(migrations, func(a, b *Migration) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+
+	return migrations, nil
+}
@@ -0,0 +1,133 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	pgx "github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+
+	"github.com/dexlabsio/garlic/errors"
+	"github.com/dexlabsio/garlic/logging"
+)
+
+// Notification is a notification received on a channel, with its payload
+// decoded from JSON.
+type Notification[T any] struct {
+	Channel string
+	PID     uint32
+	Payload T
+}
+
+// Notify sends a notification with the payload encoded as JSON. Inside a
+// transaction, it's only delivered once the transaction commits, and not
+// at all when it rolls back.
+func (db *Database) Notify(ctx context.Context, channel string, payload any) error {
+	ectx := errors.Context(
+		errors.Field("channel", channel),
+	)
+
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return errors.PropagateAs(errors.KindSystemError, err, "failed to marshal notification payload", ectx)
+	}
+
+	if _, err := db.RawExec(ctx, "SELECT pg_notify($1, $2)", channel, string(raw)); err != nil {
+		return errors.Propagate(err, "failed to send notification", ectx)
+	}
+
+	return nil
+}
+
+// Listen listens to the channels on a dedicated connection, calling handler
+// with every notification received, until the context is done. Handler
+// errors are logged and don't stop the listener. When the connection is
+// lost, it's established again with backoff; notifications sent meanwhile
+// are lost, so listeners needing every event should also poll.
+func (db *Database) Listen(ctx context.Context, channels []string, handler func(context.Context, *pgconn.Notification) error) error {
+	l := logging.GetLoggerFromContextOrGlobal(ctx)
+
+	operation := func() error {
+		err := db.withConn(ctx, func(conn *pgx.Conn) error {
+			return listen(ctx, conn, channels, handler, l)
+		})
+		if ctx.Err() != nil {
+			return backoff.Permanent(ctx.Err())
+		}
+
+		// Garlic errors, like a driver without native connections, won't
+		// go away by connecting again.
+		if errors.IsKind(err, errors.KindSystemError) {
+			return backoff.Permanent(err)
+		}
+
+		return err
+	}
+
+	notify := func(err error, delay time.Duration) {
+		l.Warn("Database listener disconnected", zap.Strings("channels", channels), zap.Duration("delay", delay), errors.Zap(err))
+	}
+
+	expBackoff := backoff.NewExponentialBackOff()
+	expBackoff.MaxElapsedTime = 0 // Listens until the context is done
+
+	err := backoff.RetryNotify(operation, backoff.WithContext(expBackoff, ctx), notify)
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	return TranslateError(errors.KindSystemError, err, "failed to listen to channels", errors.Context(
+		errors.Field("channels", channels),
+	))
+}
+
+func listen(ctx context.Context, conn *pgx.Conn, channels []string, handler func(context.Context, *pgconn.Notification) error, l *zap.Logger) error {
+	// The connection goes back to the pool afterwards, so it must stop
+	// listening, even though the context may be done already.
+	defer func() {
+		_, _ = conn.Exec(context.WithoutCancel(ctx), "UNLISTEN *")
+	}()
+
+	for _, channel := range channels {
+		if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+			return err
+		}
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		if err := handler(ctx, notification); err != nil {
+			l.Error(
+				"Failed to handle database notification",
+				zap.String("channel", notification.Channel),
+				errors.Zap(err),
+			)
+		}
+	}
+}
+
+// Subscribe listens to the channel like Database.Listen, decoding the JSON
+// payload of each notification into T. Notifications whose payload can't
+// be decoded are logged and skipped.
+func Subscribe[T any](ctx context.Context, db *Database, channel string, handler func(context.Context, *Notification[T]) error) error {
+	return db.Listen(ctx, []string{channel}, func(ctx context.Context, n *pgconn.Notification) error {
+		notification := &Notification[T]{Channel: n.Channel, PID: n.PID}
+		if err := json.Unmarshal([]byte(n.Payload), &notification.Payload); err != nil {
+			return errors.PropagateAs(
+				errors.KindSystemError,
+				err,
+				"failed to decode notification payload",
+				errors.Context(errors.Field("payload", n.Payload)),
+			)
+		}
+
+		return handler(ctx, notification)
+	})
+}
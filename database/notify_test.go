@@ -0,0 +1,34 @@
+//go:build unit
+// +build unit
+
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotify(t *testing.T) {
+	rec, sqlDB := newRecorder()
+	db := &Database{config: Defaults(), DB: sqlDB}
+
+	err := NewStorer(db).Transaction(context.Background(), func(ctx context.Context) error {
+		return db.Notify(ctx, "users", map[string]string{"id": "1"})
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"BEGIN", "SELECT pg_notify($1, $2)", "COMMIT"}, rec.Statements())
+}
+
+func TestListenRequiresPgx(t *testing.T) {
+	_, sqlDB := newRecorder()
+	db := &Database{config: Defaults(), DB: sqlDB}
+
+	// Connections that aren't pgx ones can't listen, which is not retried.
+	err := db.Listen(context.Background(), []string{"users"}, func(context.Context, *pgconn.Notification) error {
+		return nil
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,94 @@
+package database
+
+import (
+	"database/sql"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/dexlabsio/garlic/errors"
+)
+
+// SQLSTATE codes of the errors solved by retrying the transaction.
+const (
+	SerializationFailureCode = "40001"
+	DeadlockDetectedCode     = "40P01"
+)
+
+// DefaultTxMaxRetries is how many times Storer.Transaction retries a
+// function failing with a serialization failure or a deadlock. Retrying
+// runs the function again, so it's disabled unless opted in with the
+// MaxRetries option.
+const DefaultTxMaxRetries = 0
+
+// TxOptions configure the transactions started by Storer.Transaction and
+// BeginContext. They only apply to the outermost transaction; nested
+// calls run in savepoints and inherit its settings.
+type TxOptions struct {
+	Isolation  sql.IsolationLevel
+	ReadOnly   bool
+	Deferrable bool
+	MaxRetries int
+}
+
+type TxOption func(*TxOptions)
+
+// Isolation sets the isolation level of the transaction.
+func Isolation(level sql.IsolationLevel) TxOption {
+	return func(o *TxOptions) {
+		o.Isolation = level
+	}
+}
+
+// ReadOnly rejects writes in the transaction.
+func ReadOnly() TxOption {
+	return func(o *TxOptions) {
+		o.ReadOnly = true
+	}
+}
+
+// Deferrable makes serializable read-only transactions wait for a snapshot
+// free of serialization anomalies instead of failing. Postgres ignores it
+// for other transactions.
+func Deferrable() TxOption {
+	return func(o *TxOptions) {
+		o.Deferrable = true
+	}
+}
+
+// MaxRetries retries the transaction up to the given times when it fails
+// with a serialization failure or a deadlock. Zero, the default, disables
+// retrying.
+func MaxRetries(retries int) TxOption {
+	return func(o *TxOptions) {
+		o.MaxRetries = retries
+	}
+}
+
+func NewTxOptions(opts ...TxOption) *TxOptions {
+	options := &TxOptions{
+		Isolation:  sql.LevelDefault,
+		MaxRetries: DefaultTxMaxRetries,
+	}
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	return options
+}
+
+// RetryableCode reports whether the error is a serialization failure or a
+// deadlock, returning its SQLSTATE code.
+func RetryableCode(err error) (string, bool) {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return "", false
+	}
+
+	switch pgErr.Code {
+	case SerializationFailureCode, DeadlockDetectedCode:
+		return pgErr.Code, true
+	}
+
+	return "", false
+}
@@ -0,0 +1,42 @@
+package database
+
+import (
+	"strings"
+
+	pgx "github.com/jackc/pgx/v5"
+)
+
+// OrderBy builds an ORDER BY clause, prefixed with a space, or an empty
+// string when there is no order. Columns are quoted as identifiers.
+func OrderBy(orders ...Order) string {
+	if len(orders) == 0 {
+		return ""
+	}
+
+	terms := make([]string, len(orders))
+	for i, order := range orders {
+		terms[i] = Order{Column: quoteIdentifier(order.Column), Descending: order.Descending}.String()
+	}
+
+	return " ORDER BY " + strings.Join(terms, ", ")
+}
+
+// ColumnList builds the comma separated list of columns of a SELECT,
+// quoted as identifiers, or * when there is no column.
+func ColumnList(columns ...string) string {
+	if len(columns) == 0 {
+		return "*"
+	}
+
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = quoteIdentifier(column)
+	}
+
+	return strings.Join(quoted, ", ")
+}
+
+// quoteIdentifier quotes a column, which may be qualified by a table.
+func quoteIdentifier(column string) string {
+	return pgx.Identifier(strings.Split(column, ".")).Sanitize()
+}
@@ -0,0 +1,70 @@
+package outbox
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrConfigMissingTable       = errors.New("invalid Table; it can't be empty")
+	ErrConfigInvalidPoll        = errors.New("invalid PollInterval; it must be positive")
+	ErrConfigInvalidBatchSize   = errors.New("invalid BatchSize; it must be positive")
+	ErrConfigInvalidMaxAttempts = errors.New("invalid MaxAttempts; it must be positive")
+	ErrConfigInvalidRetryDelay  = errors.New("invalid RetryDelay; it must be positive and not above MaxRetryDelay")
+)
+
+type Config struct {
+	// Table stores the events until they're published.
+	Table string `mapstructure:"table" yaml:"table"`
+
+	// Channel is notified when events are written, waking up the relays
+	// listening to it. Empty disables notifications, leaving relays to poll.
+	Channel string `mapstructure:"channel" yaml:"channel"`
+
+	// PollInterval is how often relays look for events regardless of
+	// notifications, which may be lost while reconnecting.
+	PollInterval time.Duration `mapstructure:"poll_interval" yaml:"poll_interval"`
+
+	// BatchSize is how many events a relay claims at once.
+	BatchSize int `mapstructure:"batch_size" yaml:"batch_size"`
+
+	// MaxAttempts is how many times an event is published before it's left
+	// in the table as failed, for inspection.
+	MaxAttempts int `mapstructure:"max_attempts" yaml:"max_attempts"`
+
+	// RetryDelay is the delay before retrying an event, doubled on every
+	// attempt up to MaxRetryDelay.
+	RetryDelay    time.Duration `mapstructure:"retry_delay" yaml:"retry_delay"`
+	MaxRetryDelay time.Duration `mapstructure:"max_retry_delay" yaml:"max_retry_delay"`
+}
+
+func Defaults() *Config {
+	return &Config{
+		Table:         "outbox",
+		Channel:       "garlic_outbox",
+		PollInterval:  5 * time.Second,
+		BatchSize:     100,
+		MaxAttempts:   10,
+		RetryDelay:    time.Second,
+		MaxRetryDelay: 5 * time.Minute,
+	}
+}
+
+// Validate checks that the config can drive a relay: polling and batches
+// must progress, and events must be attempted at least once.
+func (c *Config) Validate() error {
+	switch {
+	case c.Table == "":
+		return ErrConfigMissingTable
+	case c.PollInterval <= 0:
+		return ErrConfigInvalidPoll
+	case c.BatchSize <= 0:
+		return ErrConfigInvalidBatchSize
+	case c.MaxAttempts <= 0:
+		return ErrConfigInvalidMaxAttempts
+	case c.RetryDelay <= 0 || c.MaxRetryDelay < c.RetryDelay:
+		return ErrConfigInvalidRetryDelay
+	}
+
+	return nil
+}
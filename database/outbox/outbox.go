@@ -0,0 +1,137 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	pgx "github.com/jackc/pgx/v5"
+
+	"github.com/dexlabsio/garlic/database"
+	"github.com/dexlabsio/garlic/errors"
+)
+
+// Event is a domain event kept in the outbox until it's published.
+type Event struct {
+	Id             uuid.UUID       `db:"id" json:"id"`
+	Topic          string          `db:"topic" json:"topic"`
+	IdempotencyKey string          `db:"idempotency_key" json:"idempotency_key"`
+	Payload        json.RawMessage `db:"payload" json:"payload"`
+	Attempts       int             `db:"attempts" json:"attempts"`
+	LastError      *string         `db:"last_error" json:"last_error,omitempty"`
+	CreatedAt      time.Time       `db:"created_at" json:"created_at"`
+	AvailableAt    time.Time       `db:"available_at" json:"available_at"`
+	PublishedAt    *time.Time      `db:"published_at" json:"published_at,omitempty"`
+}
+
+// Outbox stores domain events in a table in the same transaction as the
+// changes they describe, so events are published if and only if the
+// changes are committed. Events are then published by a Relay.
+type Outbox struct {
+	db     *database.Database
+	config *Config
+	table  string
+}
+
+// New creates the outbox of the table of the config, failing when the
+// config is invalid.
+func New(db *database.Database, config *Config) (*Outbox, error) {
+	if err := config.Validate(); err != nil {
+		return nil, errors.PropagateAs(errors.KindSystemError, err, "invalid outbox config", errors.Context(
+			errors.Field("table", config.Table),
+		))
+	}
+
+	return &Outbox{
+		db:     db,
+		config: config,
+		table:  pgx.Identifier(strings.Split(config.Table, ".")).Sanitize(),
+	}, nil
+}
+
+// Schema returns the statements creating the outbox table, meant to be
+// added to the migrations of the service.
+func (o *Outbox) Schema() string {
+	parts := strings.Split(o.config.Table, ".")
+	index := pgx.Identifier{parts[len(parts)-1] + "_pending_idx"}.Sanitize()
+
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %[1]s (
+	id uuid PRIMARY KEY,
+	topic text NOT NULL,
+	idempotency_key text NOT NULL UNIQUE,
+	payload jsonb NOT NULL,
+	attempts integer NOT NULL DEFAULT 0,
+	last_error text,
+	created_at timestamptz NOT NULL DEFAULT now(),
+	available_at timestamptz NOT NULL DEFAULT now(),
+	published_at timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS %[2]s ON %[1]s (available_at) WHERE published_at IS NULL;
+`, o.table, index)
+}
+
+// Write stores an event of the topic with the payload encoded as JSON. It
+// must be called within the transaction of the changes, see
+// database.Transaction. The idempotency key identifies the event for its
+// consumers; writing again a key already in the outbox is ignored, so
+// retried operations don't publish twice. Without a key, a random one is
+// used.
+func (o *Outbox) Write(ctx context.Context, topic, key string, payload any) error {
+	ectx := errors.Context(
+		errors.Field("topic", topic),
+		errors.Field("idempotency_key", key),
+	)
+
+	if database.Transaction(ctx) == nil {
+		return errors.New(errors.KindSystemError, "outbox events must be written in a transaction", ectx)
+	}
+
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return errors.PropagateAs(errors.KindSystemError, err, "failed to marshal event payload", ectx)
+	}
+
+	id := uuid.New()
+	if key == "" {
+		key = id.String()
+	}
+
+	query := "-- name: outbox.write\n" +
+		"INSERT INTO " + o.table + " (id, topic, idempotency_key, payload) VALUES ($1, $2, $3, $4)" +
+		" ON CONFLICT (idempotency_key) DO NOTHING"
+	if _, err := o.db.RawExec(ctx, query, id, topic, key, raw); err != nil {
+		return errors.Propagate(err, "failed to write event to the outbox", ectx)
+	}
+
+	// Delivered on commit, waking up the relays.
+	if o.config.Channel != "" {
+		if err := o.db.Notify(ctx, o.config.Channel, topic); err != nil {
+			return errors.Propagate(err, "failed to notify outbox relays", ectx)
+		}
+	}
+
+	return nil
+}
+
+// Purge deletes the events published before the given time and returns
+// how many were deleted. Failed events are kept.
+func (o *Outbox) Purge(ctx context.Context, before time.Time) (int64, error) {
+	query := "-- name: outbox.purge\n" +
+		"DELETE FROM " + o.table + " WHERE published_at < $1"
+
+	res, err := o.db.RawExec(ctx, query, before)
+	if err != nil {
+		return 0, errors.Propagate(err, "failed to purge outbox")
+	}
+
+	purged, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.PropagateAs(errors.KindSystemError, err, "failed to get purged events")
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,63 @@
+//go:build unit
+// +build unit
+
+package outbox
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dexlabsio/garlic/database"
+	"github.com/dexlabsio/garlic/errors"
+)
+
+func TestSchema(t *testing.T) {
+	config := Defaults()
+	config.Table = "events.outbox"
+
+	o, err := New(&database.Database{}, config)
+	assert.NoError(t, err)
+
+	schema := o.Schema()
+	assert.Contains(t, schema, `CREATE TABLE IF NOT EXISTS "events"."outbox" (`)
+	assert.Contains(t, schema, `CREATE INDEX IF NOT EXISTS "outbox_pending_idx" ON "events"."outbox" (available_at) WHERE published_at IS NULL;`)
+}
+
+func TestWriteRequiresTransaction(t *testing.T) {
+	o, err := New(&database.Database{}, Defaults())
+	assert.NoError(t, err)
+
+	err = o.Write(context.Background(), "user.created", "user-1", map[string]string{})
+	assert.True(t, errors.IsKind(err, errors.KindSystemError))
+}
+
+func TestRetryDelay(t *testing.T) {
+	config := Defaults()
+	config.RetryDelay = time.Second
+	config.MaxRetryDelay = 10 * time.Second
+
+	assert.Equal(t, time.Second, retryDelay(config, 1))
+	assert.Equal(t, 2*time.Second, retryDelay(config, 2))
+	assert.Equal(t, 8*time.Second, retryDelay(config, 4))
+	assert.Equal(t, 10*time.Second, retryDelay(config, 5))
+	assert.Equal(t, 10*time.Second, retryDelay(config, 50))
+}
+
+func TestNewValidatesConfig(t *testing.T) {
+	for _, invalidate := range []func(*Config){
+		func(c *Config) { c.Table = "" },
+		func(c *Config) { c.PollInterval = 0 },
+		func(c *Config) { c.BatchSize = 0 },
+		func(c *Config) { c.MaxAttempts = 0 },
+		func(c *Config) { c.MaxRetryDelay = c.RetryDelay / 2 },
+	} {
+		config := Defaults()
+		invalidate(config)
+
+		_, err := New(&database.Database{}, config)
+		assert.True(t, errors.IsKind(err, errors.KindSystemError))
+	}
+}
@@ -0,0 +1,168 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+
+	"github.com/dexlabsio/garlic/database"
+	"github.com/dexlabsio/garlic/errors"
+	"github.com/dexlabsio/garlic/logging"
+)
+
+// Publisher delivers events to consumers, such as a message broker. Events
+// are delivered at least once, so consumers should deduplicate them by
+// their idempotency key.
+type Publisher interface {
+	Publish(ctx context.Context, event *Event) error
+}
+
+// PublisherFunc adapts a function to a Publisher.
+type PublisherFunc func(ctx context.Context, event *Event) error
+
+func (f PublisherFunc) Publish(ctx context.Context, event *Event) error {
+	return f(ctx, event)
+}
+
+// Relay publishes the events of the outbox. Several relays can run at
+// once, in one or many replicas; each event is claimed by a single relay at
+// a time. Failed events are retried with backoff up to the MaxAttempts of
+// the config. Events are claimed oldest first, but the order they're
+// published in is best-effort: concurrent relays and delayed retries let
+// newer events through first, so consumers must not rely on it.
+type Relay struct {
+	outbox    *Outbox
+	publisher Publisher
+}
+
+func (o *Outbox) Relay(publisher Publisher) *Relay {
+	return &Relay{outbox: o, publisher: publisher}
+}
+
+// Run publishes events until the context is done. It wakes up when events
+// are written, if the config has a notification channel, and every poll
+// interval.
+func (r *Relay) Run(ctx context.Context) {
+	config := r.outbox.config
+	l := logging.GetLoggerFromContextOrGlobal(ctx)
+
+	wake := make(chan struct{}, 1)
+	if config.Channel != "" {
+		go func() {
+			err := r.outbox.db.Listen(ctx, []string{config.Channel}, func(context.Context, *pgconn.Notification) error {
+				select {
+				case wake <- struct{}{}:
+				default: // A wake up is already pending
+				}
+				return nil
+			})
+			if err != nil {
+				l.Warn("Outbox relay stopped listening for events, polling only", errors.Zap(err))
+			}
+		}()
+	}
+
+	ticker := time.NewTicker(config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Full batches mean there may be more pending events.
+		for {
+			handled, err := r.Dispatch(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					l.Error("Failed to dispatch outbox events", errors.Zap(err))
+				}
+				break
+			}
+
+			if handled < config.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-wake:
+		}
+	}
+}
+
+// Dispatch claims a batch of pending events and publishes them, returning
+// how many were handled, published or not. Events are claimed with row
+// locks held until they're all handled, which other relays skip. An event
+// published right before the database fails to record it is published
+// again later.
+func (r *Relay) Dispatch(ctx context.Context) (int, error) {
+	config := r.outbox.config
+	db := r.outbox.db
+	l := logging.GetLoggerFromContextOrGlobal(ctx)
+
+	claim := "-- name: outbox.claim\n" +
+		"SELECT * FROM " + r.outbox.table +
+		" WHERE published_at IS NULL AND attempts < $1 AND available_at <= now()" +
+		" ORDER BY created_at, id LIMIT $2 FOR UPDATE SKIP LOCKED"
+
+	published := "-- name: outbox.published\n" +
+		"UPDATE " + r.outbox.table + " SET attempts = attempts + 1, last_error = NULL, published_at = now() WHERE id = $1"
+
+	failed := "-- name: outbox.failed\n" +
+		"UPDATE " + r.outbox.table + " SET attempts = attempts + 1, last_error = $2," +
+		" available_at = now() + $3 * interval '1 millisecond' WHERE id = $1"
+
+	handled := 0
+	err := database.NewStorer(db).Transaction(ctx, func(ctx context.Context) error {
+		events := []*Event{}
+		if err := db.List(ctx, claim, &events, config.MaxAttempts, config.BatchSize); err != nil {
+			return errors.Propagate(err, "failed to claim outbox events")
+		}
+
+		for _, event := range events {
+			attempt := event.Attempts + 1
+			fields := []zap.Field{
+				zap.Stringer("event_id", event.Id),
+				zap.String("topic", event.Topic),
+				zap.Int("attempt", attempt),
+			}
+
+			if err := r.publisher.Publish(ctx, event); err != nil {
+				if attempt >= config.MaxAttempts {
+					l.Error("Outbox event failed too many times, giving up", append(fields, errors.Zap(err))...)
+				} else {
+					l.Warn("Failed to publish outbox event, retrying later", append(fields, errors.Zap(err))...)
+				}
+
+				delay := retryDelay(config, attempt)
+				if _, err := db.RawExec(ctx, failed, event.Id, err.Error(), delay.Milliseconds()); err != nil {
+					return errors.Propagate(err, "failed to record outbox event failure")
+				}
+			} else if _, err := db.RawExec(ctx, published, event.Id); err != nil {
+				return errors.Propagate(err, "failed to record published outbox event")
+			}
+
+			handled++
+		}
+
+		return nil
+	}, database.MaxRetries(0))
+	if err != nil {
+		return 0, errors.Propagate(err, "failed to dispatch outbox events")
+	}
+
+	return handled, nil
+}
+
+// retryDelay is the delay before the next attempt of an event, doubled on
+// every failed attempt up to the maximum.
+func retryDelay(config *Config, attempt int) time.Duration {
+	delay := config.RetryDelay
+	for i := 1; i < attempt && delay < config.MaxRetryDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, config.MaxRetryDelay)
+}
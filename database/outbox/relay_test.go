@@ -0,0 +1,235 @@
+//go:build unit
+// +build unit
+
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dexlabsio/garlic/database"
+	"github.com/dexlabsio/garlic/errors"
+)
+
+// statement is a statement received by the recorder, named after the
+// `-- name:` comment of the query.
+type statement struct {
+	name string
+	args []any
+}
+
+// recorder is a database/sql driver recording the statements of the relay.
+// Claims return the events, and statements named by fail fail.
+type recorder struct {
+	mu         sync.Mutex
+	statements []statement
+	events     []*Event
+	fail       string
+}
+
+func newRelay(t *testing.T, r *recorder, publisher PublisherFunc) *Relay {
+	db := database.New(database.Defaults())
+	db.DB = sqlx.NewDb(sql.OpenDB(r), "pgx")
+
+	config := Defaults()
+	config.MaxAttempts = 3
+
+	o, err := New(db, config)
+	require.NoError(t, err)
+
+	return o.Relay(publisher)
+}
+
+func (r *recorder) record(query string, args []driver.NamedValue) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	name := query
+	if strings.HasPrefix(query, "-- name: ") {
+		name = strings.TrimPrefix(strings.SplitN(query, "\n", 2)[0], "-- name: ")
+	}
+
+	values := make([]any, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+
+	r.statements = append(r.statements, statement{name: name, args: values})
+	if name == r.fail {
+		return errors.New(errors.KindSystemError, "connection lost")
+	}
+
+	return nil
+}
+
+func (r *recorder) names() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := make([]string, len(r.statements))
+	for i, s := range r.statements {
+		names[i] = s.name
+	}
+
+	return names
+}
+
+func (r *recorder) Connect(context.Context) (driver.Conn, error) { return &recorderConn{r}, nil }
+func (r *recorder) Driver() driver.Driver                        { return nil }
+
+type recorderConn struct{ r *recorder }
+
+func (c *recorderConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c *recorderConn) Close() error                        { return nil }
+func (c *recorderConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *recorderConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	return &recorderTx{c.r}, c.r.record("BEGIN", nil)
+}
+
+func (c *recorderConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if err := c.r.record(query, args); err != nil {
+		return nil, err
+	}
+
+	return driver.RowsAffected(1), nil
+}
+
+func (c *recorderConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if err := c.r.record(query, args); err != nil {
+		return nil, err
+	}
+
+	return &eventRows{events: c.r.events}, nil
+}
+
+type recorderTx struct{ r *recorder }
+
+func (tx *recorderTx) Commit() error   { return tx.r.record("COMMIT", nil) }
+func (tx *recorderTx) Rollback() error { return tx.r.record("ROLLBACK", nil) }
+
+// eventRows returns the events as rows of the outbox table.
+type eventRows struct{ events []*Event }
+
+func (rows *eventRows) Columns() []string {
+	return []string{"id", "topic", "idempotency_key", "payload", "attempts", "last_error", "created_at", "available_at", "published_at"}
+}
+
+func (rows *eventRows) Close() error { return nil }
+
+func (rows *eventRows) Next(dest []driver.Value) error {
+	if len(rows.events) == 0 {
+		return io.EOF
+	}
+
+	e := rows.events[0]
+	rows.events = rows.events[1:]
+
+	copy(dest, []driver.Value{
+		e.Id.String(), e.Topic, e.IdempotencyKey, []byte(e.Payload), int64(e.Attempts), nil,
+		e.CreatedAt, e.AvailableAt, nil,
+	})
+	return nil
+}
+
+func pendingEvent(topic string, attempts int) *Event {
+	return &Event{
+		Id:             uuid.New(),
+		Topic:          topic,
+		IdempotencyKey: topic,
+		Payload:        []byte(`{}`),
+		Attempts:       attempts,
+		CreatedAt:      time.Now(),
+		AvailableAt:    time.Now(),
+	}
+}
+
+func TestDispatchPublishesClaimedEvents(t *testing.T) {
+	first, second := pendingEvent("user.created", 0), pendingEvent("user.updated", 0)
+	r := &recorder{events: []*Event{first, second}}
+
+	published := []string{}
+	relay := newRelay(t, r, func(ctx context.Context, event *Event) error {
+		// Events are marked as published only after being published.
+		marked := 0
+		for _, name := range r.names() {
+			if name == "outbox.published" {
+				marked++
+			}
+		}
+		assert.Equal(t, len(published), marked)
+		assert.NotNil(t, database.Transaction(ctx))
+
+		published = append(published, event.Topic)
+		return nil
+	})
+
+	handled, err := relay.Dispatch(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, handled)
+	assert.Equal(t, []string{"user.created", "user.updated"}, published)
+	assert.Equal(t, []string{"BEGIN", "outbox.claim", "outbox.published", "outbox.published", "COMMIT"}, r.names())
+
+	assert.Equal(t, []any{int64(3), int64(100)}, r.statements[1].args)
+	assert.Equal(t, first.Id.String(), r.statements[2].args[0])
+	assert.Equal(t, second.Id.String(), r.statements[3].args[0])
+}
+
+func TestDispatchRecordsFailures(t *testing.T) {
+	event := pendingEvent("user.created", 1)
+	r := &recorder{events: []*Event{event}}
+
+	relay := newRelay(t, r, func(ctx context.Context, event *Event) error {
+		return errors.New(errors.KindSystemError, "broker unavailable")
+	})
+
+	handled, err := relay.Dispatch(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, handled)
+	assert.Equal(t, []string{"BEGIN", "outbox.claim", "outbox.failed", "COMMIT"}, r.names())
+
+	// Second attempt, retried after twice the retry delay.
+	failed := r.statements[2]
+	assert.Equal(t, []any{event.Id.String(), "broker unavailable", int64(2000)}, failed.args)
+}
+
+func TestDispatchGivesUp(t *testing.T) {
+	// The last attempt allowed by MaxAttempts. Once recorded, the claim
+	// skips the event, leaving it in the table as failed.
+	event := pendingEvent("user.created", 2)
+	r := &recorder{events: []*Event{event}}
+
+	relay := newRelay(t, r, func(ctx context.Context, event *Event) error {
+		return errors.New(errors.KindSystemError, "rejected")
+	})
+
+	handled, err := relay.Dispatch(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, handled)
+	assert.Equal(t, []string{"BEGIN", "outbox.claim", "outbox.failed", "COMMIT"}, r.names())
+	assert.Equal(t, []any{event.Id.String(), "rejected", int64(4000)}, r.statements[2].args)
+}
+
+func TestDispatchRollsBackWhenRecordingFails(t *testing.T) {
+	r := &recorder{events: []*Event{pendingEvent("user.created", 0)}, fail: "outbox.published"}
+
+	relay := newRelay(t, r, func(ctx context.Context, event *Event) error { return nil })
+
+	handled, err := relay.Dispatch(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, 0, handled)
+	assert.Equal(t, []string{"BEGIN", "outbox.claim", "outbox.published", "ROLLBACK"}, r.names())
+}
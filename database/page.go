@@ -0,0 +1,282 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx/reflectx"
+
+	"github.com/dexlabsio/garlic/errors"
+	"github.com/dexlabsio/garlic/pagination"
+)
+
+var columnReg = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// Order, Page and PageResult live in the pagination package, which has no
+// database dependencies, so requests can be parsed into them.
+type (
+	Order      = pagination.Order
+	Page       = pagination.Page
+	PageResult = pagination.PageResult
+)
+
+// ListPage runs the query, wrapped as a subquery, selecting a single page
+// of results into resourceList, which must be a pointer to a slice. The
+// order, the keyset predicate of the cursor and the limit are appended by
+// ListPage, so the query must not have them.
+func (db *Database) ListPage(ctx context.Context, query string, resourceList any, page *Page, args ...any) (_ *PageResult, err error) {
+	ctx, end := db.instrument(ctx, opListPage, query)
+	defer end(&err)
+
+	ectx := errors.Context(
+		errors.Field("query", query),
+		errors.Field("args", args),
+	)
+
+	pageQuery, pageArgs, err := buildPageQuery(query, page, args)
+	if err != nil {
+		return nil, errors.Propagate(err, "failed to build page query", ectx)
+	}
+
+	err = db.read(ctx, func(executor Executor) error {
+		return executor.Select(resourceList, pageQuery, pageArgs...)
+	})
+	if err != nil {
+		return nil, TranslateError(errors.KindSystemError, err, "failed to select page of resources", ectx)
+	}
+
+	result := &PageResult{Limit: page.Limit, Offset: page.Offset}
+
+	// One extra row was fetched to know whether there are more pages.
+	items := reflect.ValueOf(resourceList).Elem()
+	if items.Len() > page.Limit {
+		result.HasMore = true
+		items.Set(items.Slice(0, page.Limit))
+
+		if len(page.OrderBy) > 0 {
+			result.NextCursor, err = encodeCursor(db.Mapper, items.Index(page.Limit-1), page.OrderBy)
+			if err != nil {
+				return nil, errors.Propagate(err, "failed to encode next cursor", ectx)
+			}
+		}
+	}
+
+	if page.WithTotal {
+		var total int
+		err := db.read(ctx, func(executor Executor) error {
+			return executor.Get(&total, "SELECT count(*) FROM ("+query+") AS total", args...)
+		})
+		if err != nil {
+			return nil, TranslateError(errors.KindSystemError, err, "failed to count resources", ectx)
+		}
+		result.Total = &total
+	}
+
+	return result, nil
+}
+
+// buildPageQuery wraps the query with the keyset predicate, the order and
+// the limit of the page. Arguments of the cursor are appended to args.
+func buildPageQuery(query string, page *Page, args []any) (string, []any, error) {
+	if page.Limit < 1 {
+		return "", nil, errors.New(
+			errors.KindSystemError,
+			"page limit must be positive",
+			errors.Context(errors.Field("limit", page.Limit)),
+		)
+	}
+
+	for _, order := range page.OrderBy {
+		if !columnReg.MatchString(order.Column) {
+			return "", nil, errors.New(
+				errors.KindSystemError,
+				"invalid order column",
+				errors.Context(errors.Field("column", order.Column)),
+			)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("SELECT * FROM (" + query + ") AS page")
+
+	if page.Cursor != "" {
+		if len(page.OrderBy) == 0 {
+			return "", nil, errors.New(
+				errors.KindInvalidRequestError,
+				"page cursor requires a sort order",
+				errors.Hint("Please send the cursor with the sort order of the page it was returned by."),
+			)
+		}
+
+		values, err := pagination.DecodeCursor(page.Cursor, len(page.OrderBy))
+		if err != nil {
+			return "", nil, err
+		}
+
+		var predicate string
+		predicate, args = keysetPredicate(page.OrderBy, values, args)
+		b.WriteString(" WHERE " + predicate)
+	}
+
+	if len(page.OrderBy) > 0 {
+		orders := make([]string, len(page.OrderBy))
+		for i, order := range page.OrderBy {
+			orders[i] = order.String()
+		}
+		b.WriteString(" ORDER BY " + strings.Join(orders, ", "))
+	}
+
+	b.WriteString(" LIMIT " + strconv.Itoa(page.Limit+1))
+
+	if page.Cursor == "" && page.Offset > 0 {
+		b.WriteString(" OFFSET " + strconv.Itoa(page.Offset))
+	}
+
+	return b.String(), args, nil
+}
+
+// keysetPredicate selects the rows after the cursor values in the page
+// order. Mixed directions are supported by expanding the row comparison:
+//
+//	(a > $1) OR (a = $1 AND b < $2) OR ...
+func keysetPredicate(orderBy []Order, values []string, args []any) (string, []any) {
+	placeholders := make([]string, len(values))
+	for i, value := range values {
+		args = append(args, value)
+		placeholders[i] = "$" + strconv.Itoa(len(args))
+	}
+
+	alternatives := make([]string, len(orderBy))
+	for i, order := range orderBy {
+		terms := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			terms = append(terms, orderBy[j].Column+" = "+placeholders[j])
+		}
+
+		comparison := " > "
+		if order.Descending {
+			comparison = " < "
+		}
+		terms = append(terms, order.Column+comparison+placeholders[i])
+
+		alternatives[i] = "(" + strings.Join(terms, " AND ") + ")"
+	}
+
+	return "(" + strings.Join(alternatives, " OR ") + ")", args
+}
+
+// encodeCursor encodes the order column values of the item as an opaque
+// cursor. Values are kept in their text representation, which postgres
+// casts back to the column types.
+func encodeCursor(mapper *reflectx.Mapper, item reflect.Value, orderBy []Order) (string, error) {
+	item = reflect.Indirect(item)
+
+	values := make([]string, len(orderBy))
+	for i, order := range orderBy {
+		field, ok := fieldByColumn(mapper, item, order.Column)
+		if !ok {
+			return "", errors.New(
+				errors.KindSystemError,
+				"order column is not mapped by the resource",
+				errors.Context(
+					errors.Field("column", order.Column),
+					errors.Field("resource", item.Type().String()),
+				),
+			)
+		}
+
+		value, err := cursorValue(field.Interface())
+		if err != nil {
+			return "", errors.Propagate(err, "failed to encode cursor value", errors.Context(errors.Field("column", order.Column)))
+		}
+		values[i] = value
+	}
+
+	return pagination.EncodeCursor(values)
+}
+
+// fieldByColumn finds the field of the struct mapped to the column. Unlike
+// Mapper.FieldByName, it never allocates nil pointers on the way, so the
+// struct is left untouched and a nil embedded pointer yields a nil field.
+func fieldByColumn(mapper *reflectx.Mapper, item reflect.Value, column string) (reflect.Value, bool) {
+	fi := mapper.TypeMap(item.Type()).GetByPath(column)
+	if fi == nil {
+		return reflect.Value{}, false
+	}
+
+	v := item
+	for _, i := range fi.Index {
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Zero(reflect.PointerTo(fi.Field.Type)), true
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+
+	return v, true
+}
+
+// cursorValue converts a column value into its text representation, in a
+// format postgres parses back into the column type. Keyset pagination
+// can't compare nulls, so null values are rejected.
+func cursorValue(value any) (string, error) {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "", errors.New(errors.KindSystemError, "order column values can't be null")
+		}
+		v = v.Elem()
+	}
+	value = v.Interface()
+
+	if valuer, ok := value.(driver.Valuer); ok {
+		v, err := valuer.Value()
+		if err != nil {
+			return "", err
+		}
+		if v == nil {
+			return "", errors.New(errors.KindSystemError, "order column values can't be null")
+		}
+		value = v
+	}
+
+	switch v := value.(type) {
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	case fmt.Stringer:
+		return v.String(), nil
+	}
+
+	switch v := reflect.ValueOf(value); v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.String:
+		return v.String(), nil
+	}
+
+	return "", errors.New(
+		errors.KindSystemError,
+		"unsupported order column type",
+		errors.Context(errors.Field("type", fmt.Sprintf("%T", value))),
+	)
+}
@@ -0,0 +1,98 @@
+//go:build unit
+// +build unit
+
+package database
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx/reflectx"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dexlabsio/garlic/errors"
+	"github.com/dexlabsio/garlic/pagination"
+)
+
+type pageItem struct {
+	Id        int       `db:"id"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func TestBuildPageQuery(t *testing.T) {
+	orderBy := []Order{{Column: "created_at", Descending: true}, {Column: "id"}}
+
+	query, args, err := buildPageQuery("SELECT * FROM items WHERE org = $1", &Page{Limit: 10, Offset: 20, OrderBy: orderBy}, []any{"org"})
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM (SELECT * FROM items WHERE org = $1) AS page ORDER BY created_at DESC, id ASC LIMIT 11 OFFSET 20", query)
+	assert.Equal(t, []any{"org"}, args)
+
+	item := pageItem{Id: 7, CreatedAt: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)}
+	mapper := reflectx.NewMapperFunc("db", strings.ToLower)
+	cursor, err := encodeCursor(mapper, reflect.ValueOf(&item), orderBy)
+	assert.NoError(t, err)
+
+	query, args, err = buildPageQuery("SELECT * FROM items WHERE org = $1", &Page{Limit: 10, Cursor: cursor, OrderBy: orderBy}, []any{"org"})
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM (SELECT * FROM items WHERE org = $1) AS page WHERE ((created_at < $2) OR (created_at = $2 AND id > $3)) ORDER BY created_at DESC, id ASC LIMIT 11", query)
+	assert.Equal(t, []any{"org", "2024-05-01T10:00:00Z", "7"}, args)
+}
+
+func TestInvalidPages(t *testing.T) {
+	_, _, err := buildPageQuery("SELECT 1", &Page{Limit: 10, Cursor: "garbage", OrderBy: []Order{{Column: "id"}}}, nil)
+	assert.Error(t, err)
+
+	_, _, err = buildPageQuery("SELECT 1", &Page{Limit: 10, OrderBy: []Order{{Column: "id; DROP TABLE items"}}}, nil)
+	assert.Error(t, err)
+
+	_, _, err = buildPageQuery("SELECT 1", &Page{Limit: 0}, nil)
+	assert.Error(t, err)
+}
+
+func TestCursorWithoutOrder(t *testing.T) {
+	_, _, err := buildPageQuery("SELECT 1", &Page{Limit: 10, Cursor: "W10"}, nil)
+	assert.True(t, errors.IsKind(err, errors.KindInvalidRequestError))
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	type event struct {
+		Id        uuid.UUID    `db:"id"`
+		At        *time.Time   `db:"at"`
+		DeletedAt sql.NullTime `db:"deleted_at"`
+		Score     float64      `db:"score"`
+		Rank      uint8        `db:"rank"`
+	}
+
+	// The monotonic reading and the zone must not leak into the cursor.
+	at := time.Now().In(time.FixedZone("BRT", -3*60*60))
+	item := event{
+		Id:        uuid.New(),
+		At:        &at,
+		DeletedAt: sql.NullTime{Time: at, Valid: true},
+		Score:     0.1,
+		Rank:      3,
+	}
+
+	orderBy := []Order{{Column: "at"}, {Column: "deleted_at"}, {Column: "score"}, {Column: "rank"}, {Column: "id"}}
+	mapper := reflectx.NewMapperFunc("db", strings.ToLower)
+	cursor, err := encodeCursor(mapper, reflect.ValueOf(&item), orderBy)
+	assert.NoError(t, err)
+
+	values, err := pagination.DecodeCursor(cursor, len(orderBy))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0.1", "3", item.Id.String()}, values[2:])
+
+	for _, value := range values[:2] {
+		decoded, err := time.Parse(time.RFC3339Nano, value)
+		assert.NoError(t, err)
+		assert.True(t, at.Equal(decoded))
+	}
+
+	item.At = nil
+	_, err = encodeCursor(mapper, reflect.ValueOf(&item), orderBy)
+	assert.Error(t, err)
+}
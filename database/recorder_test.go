@@ -0,0 +1,135 @@
+//go:build unit
+// +build unit
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// recorder is a database/sql driver recording the statements it receives,
+// used to check the transaction control flow without a database. Exec
+// and commits fail with the error returned by fail, when set, and Exec
+// affects the number of rows returned by affected, or a single row.
+// Queries return the single value returned by value, when set, or no rows.
+// Pings fail like a "PING" statement, without being recorded.
+type recorder struct {
+	mu         sync.Mutex
+	statements []string
+	fail       func(query string) error
+	affected   func(query string) int64
+	value      func(query string) driver.Value
+}
+
+func newRecorder() (*recorder, *sqlx.DB) {
+	r := &recorder{}
+	return r, sqlx.NewDb(sql.OpenDB(r), "pgx")
+}
+
+func (r *recorder) record(statement string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statements = append(r.statements, statement)
+}
+
+func (r *recorder) Statements() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string{}, r.statements...)
+}
+
+func (r *recorder) Connect(context.Context) (driver.Conn, error) { return &recorderConn{r}, nil }
+func (r *recorder) Driver() driver.Driver                        { return nil }
+
+type recorderConn struct{ r *recorder }
+
+func (c *recorderConn) Prepare(query string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c *recorderConn) Close() error                              { return nil }
+func (c *recorderConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *recorderConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	statement := "BEGIN"
+	if level := sql.IsolationLevel(opts.Isolation); level != sql.LevelDefault {
+		statement += " ISOLATION LEVEL " + strings.ToUpper(level.String())
+	}
+	if opts.ReadOnly {
+		statement += " READ ONLY"
+	}
+
+	c.r.record(statement)
+	return &recorderTx{c.r}, nil
+}
+
+func (c *recorderConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.r.record(query)
+	if c.r.fail != nil {
+		if err := c.r.fail(query); err != nil {
+			return nil, err
+		}
+	}
+	if c.r.affected != nil {
+		return driver.RowsAffected(c.r.affected(query)), nil
+	}
+	return driver.RowsAffected(1), nil
+}
+
+// QueryContext records the query and returns no rows.
+func (c *recorderConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.r.record(query)
+	if c.r.fail != nil {
+		if err := c.r.fail(query); err != nil {
+			return nil, err
+		}
+	}
+	if c.r.value != nil {
+		return &recorderRows{values: []driver.Value{c.r.value(query)}}, nil
+	}
+	return &recorderRows{}, nil
+}
+
+func (c *recorderConn) Ping(ctx context.Context) error {
+	if c.r.fail != nil {
+		return c.r.fail("PING")
+	}
+	return nil
+}
+
+type recorderRows struct{ values []driver.Value }
+
+func (rows *recorderRows) Columns() []string {
+	if rows.values == nil {
+		return nil
+	}
+	return []string{"value"}
+}
+
+func (rows *recorderRows) Close() error { return nil }
+
+func (rows *recorderRows) Next(dest []driver.Value) error {
+	if len(rows.values) == 0 {
+		return io.EOF
+	}
+	dest[0], rows.values = rows.values[0], rows.values[1:]
+	return nil
+}
+
+type recorderTx struct{ r *recorder }
+
+func (tx *recorderTx) Commit() error {
+	tx.r.record("COMMIT")
+	if tx.r.fail != nil {
+		return tx.r.fail("COMMIT")
+	}
+	return nil
+}
+
+func (tx *recorderTx) Rollback() error { tx.r.record("ROLLBACK"); return nil }
@@ -0,0 +1,166 @@
+package database
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"github.com/dexlabsio/garlic/errors"
+	"github.com/dexlabsio/garlic/logging"
+)
+
+// replica is a read replica, ejected from reads while it's unreachable.
+type replica struct {
+	*sqlx.DB
+	pool    *pgxpool.Pool
+	index   int
+	healthy atomic.Bool
+}
+
+// connectReplicas opens the replicas of the config and starts checking
+// their health in the background.
+func (db *Database) connectReplicas() error {
+	if len(db.config.Replicas) == 0 {
+		return nil
+	}
+
+	for i, dsn := range db.config.Replicas {
+		sqlDB, pool, err := db.open(dsn)
+		if err != nil {
+			db.closeReplicas()
+			return errors.Propagate(err, "failed to connect to replica", errors.Context(errors.Field("replica", i)))
+		}
+
+		r := &replica{DB: sqlDB, pool: pool, index: i}
+		r.healthy.Store(true)
+		db.replicas = append(db.replicas, r)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	db.stopReplicas = cancel
+	go db.watchReplicas(ctx, db.config.ReplicaCheckPeriod)
+
+	return nil
+}
+
+func (db *Database) closeReplicas() {
+	if db.stopReplicas != nil {
+		db.stopReplicas()
+	}
+
+	for _, r := range db.replicas {
+		_ = r.Close()
+		if r.pool != nil {
+			r.pool.Close()
+		}
+	}
+
+	db.replicas = nil
+}
+
+// watchReplicas pings the replicas every period, ejecting the unreachable
+// ones from reads and bringing them back once they answer again.
+func (db *Database) watchReplicas(ctx context.Context, period time.Duration) {
+	if period <= 0 {
+		period = DefaultReplicaCheckPeriod
+	}
+
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// Half the period leaves every check done before the next one.
+		db.checkReplicas(ctx, period/2)
+	}
+}
+
+// checkReplicas pings the replicas concurrently, so an unreachable one
+// doesn't delay noticing the others, waiting up to the timeout for each.
+func (db *Database) checkReplicas(ctx context.Context, timeout time.Duration) {
+	var wg sync.WaitGroup
+	for _, r := range db.replicas {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			pingCtx, cancel := context.WithTimeout(ctx, timeout)
+			err := r.PingContext(pingCtx)
+			cancel()
+
+			if err != nil {
+				db.eject(r, err)
+			} else if !r.healthy.Swap(true) {
+				logging.Global().Info("Database replica recovered", zap.Int("replica", r.index))
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+func (db *Database) eject(r *replica, err error) {
+	if r.healthy.Swap(false) {
+		logging.Global().Warn("Database replica ejected from reads", zap.Int("replica", r.index), errors.Zap(err))
+	}
+}
+
+// ReadYourWrites returns a context whose reads go to the primary, so they
+// see the writes just made outside of a transaction, which replicas may
+// not have applied yet.
+func ReadYourWrites(ctx context.Context) context.Context {
+	return context.WithValue(ctx, ReadYourWritesKey, true)
+}
+
+func readsYourWrites(ctx context.Context) bool {
+	ryw, _ := ctx.Value(ReadYourWritesKey).(bool)
+	return ryw
+}
+
+// Reader returns the executor of read only queries. It's the transaction
+// of the context, if any, the primary when the context reads its writes or
+// no replica is healthy, and otherwise the next healthy replica in turns.
+func (db *Database) Reader(ctx context.Context) Executor {
+	executor, _ := db.reader(ctx)
+	return executor
+}
+
+func (db *Database) reader(ctx context.Context) (Executor, *replica) {
+	if Transaction(ctx) != nil || readsYourWrites(ctx) || len(db.replicas) == 0 {
+		return db.Executor(ctx), nil
+	}
+
+	start := db.nextReplica.Add(1)
+	for i := range uint64(len(db.replicas)) {
+		r := db.replicas[(start+i)%uint64(len(db.replicas))]
+		if r.healthy.Load() {
+			return r.DB, r
+		}
+	}
+
+	return db.Executor(ctx), nil
+}
+
+// read runs fn with the executor of reads. When a replica turns out to be
+// unreachable, it's ejected and fn runs again on the primary.
+func (db *Database) read(ctx context.Context, fn func(Executor) error) error {
+	executor, r := db.reader(ctx)
+
+	err := fn(executor)
+	if r != nil && err != nil && errors.IsKind(TranslateError(errors.KindSystemError, err, ""), KindDatabaseConnectionError) {
+		db.eject(r, err)
+		err = fn(db.Executor(ctx))
+	}
+
+	return err
+}
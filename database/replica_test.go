@@ -0,0 +1,119 @@
+//go:build unit
+// +build unit
+
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dexlabsio/garlic/errors"
+	"github.com/dexlabsio/garlic/tracing"
+)
+
+func newReplicatedDatabase(count int) (*Database, *recorder, []*recorder) {
+	primary, sqlDB := newRecorder()
+	db := &Database{config: Defaults(), DB: sqlDB}
+
+	recorders := make([]*recorder, count)
+	for i := range recorders {
+		rec, replicaDB := newRecorder()
+		r := &replica{DB: replicaDB, index: i}
+		r.healthy.Store(true)
+		db.replicas = append(db.replicas, r)
+		recorders[i] = rec
+	}
+
+	return db, primary, recorders
+}
+
+func TestReplicaRouting(t *testing.T) {
+	db, primary, replicas := newReplicatedDatabase(2)
+	ctx := context.Background()
+	list := []struct{}{}
+
+	for range 4 {
+		assert.NoError(t, db.List(ctx, "SELECT 1", &list))
+	}
+	assert.Len(t, replicas[0].Statements(), 2)
+	assert.Len(t, replicas[1].Statements(), 2)
+
+	// Writes, reads of your writes and transactions go to the primary.
+	_, err := db.RawExec(ctx, "INSERT 1")
+	assert.NoError(t, err)
+	assert.NoError(t, db.List(ReadYourWrites(ctx), "SELECT 2", &list))
+	err = NewStorer(db).Transaction(ctx, func(ctx context.Context) error {
+		return db.List(ctx, "SELECT 3", &list)
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"INSERT 1", "SELECT 2", "BEGIN", "SELECT 3", "COMMIT"}, primary.Statements())
+}
+
+func TestReplicaEjection(t *testing.T) {
+	db, primary, replicas := newReplicatedDatabase(2)
+	ctx := context.Background()
+	list := []struct{}{}
+
+	replicas[0].fail = func(string) error { return &pgconn.PgError{Code: "57P01"} }
+
+	// The failing read is ejected and retried on the primary, then the
+	// other replica takes every read.
+	for range 3 {
+		assert.NoError(t, db.List(ctx, "SELECT 1", &list))
+	}
+	assert.False(t, db.replicas[0].healthy.Load())
+	assert.Len(t, replicas[0].Statements(), 1)
+	assert.Len(t, replicas[1].Statements(), 2)
+	assert.Equal(t, []string{"SELECT 1"}, primary.Statements())
+
+	// Without healthy replicas, reads go to the primary.
+	db.replicas[1].healthy.Store(false)
+	assert.NoError(t, db.List(ctx, "SELECT 2", &list))
+	assert.Equal(t, []string{"SELECT 1", "SELECT 2"}, primary.Statements())
+}
+
+func TestReplicaChecks(t *testing.T) {
+	db, _, replicas := newReplicatedDatabase(3)
+
+	// The first replica answers only once the second was pinged, so the
+	// pings must run concurrently.
+	pinged := make(chan struct{})
+	replicas[0].fail = func(string) error {
+		select {
+		case <-pinged:
+			return nil
+		case <-time.After(time.Second):
+			return errors.New(errors.KindSystemError, "ping timeout")
+		}
+	}
+	replicas[1].fail = func(string) error { close(pinged); return nil }
+	replicas[2].fail = func(string) error { return &pgconn.PgError{Code: "57P01"} }
+	db.replicas[1].healthy.Store(false)
+
+	db.checkReplicas(context.Background(), time.Second)
+
+	assert.True(t, db.replicas[0].healthy.Load())
+	assert.True(t, db.replicas[1].healthy.Load())
+	assert.False(t, db.replicas[2].healthy.Load())
+}
+
+func TestRepositoryWritesGoToThePrimary(t *testing.T) {
+	db, primary, replicas := newReplicatedDatabase(1)
+	repo := NewRepository[repoDocument](db, "documents")
+
+	tracer := &spanRecorder{}
+	tracing.SetTracer(tracer)
+	defer tracing.SetTracer(nil)
+
+	_, err := repo.Restore(context.Background(), "doc")
+	assert.True(t, errors.IsKind(err, errors.KindNotFoundError))
+
+	assert.Len(t, primary.Statements(), 1)
+	assert.Empty(t, replicas[0].Statements())
+	assert.Equal(t, []string{"db.update documents.restore"}, tracer.names)
+}
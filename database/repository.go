@@ -0,0 +1,484 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/dexlabsio/garlic/database/utils"
+	"github.com/dexlabsio/garlic/errors"
+)
+
+// Options of the repo tag, as in `db:"id" repo:"pk,readonly"`.
+const (
+	// RepoPrimaryKey marks the primary key column. When no field has it,
+	// the column named id is used.
+	RepoPrimaryKey = "pk"
+
+	// RepoReadOnly marks columns written only by the database, such as
+	// generated keys and defaults. They're returned but never inserted or
+	// patched.
+	RepoReadOnly = "readonly"
+
+	// RepoSoftDelete marks a nullable timestamp set when the resource is
+	// deleted. Deleted resources are hidden from Get, List and Patch, and
+	// can be brought back with Restore.
+	RepoSoftDelete = "soft_delete"
+
+	// RepoVersion marks an integer column incremented on every change and
+	// checked by Update and Patch for optimistic concurrency.
+	RepoVersion = "version"
+
+	// RepoCreatedAt and RepoUpdatedAt mark timestamps maintained with the
+	// clock of the database on insert and on every change.
+	RepoCreatedAt = "created_at"
+	RepoUpdatedAt = "updated_at"
+)
+
+// Repository implements the common CRUD queries of a table whose rows are
+// mapped to T through db tags. Queries run in the transaction of the
+// context, if any, like the Database methods they're built on.
+type Repository[T any] struct {
+	db         *Database
+	name       string
+	table      string
+	primaryKey string
+	columns    []string
+	writable   map[string]bool
+	index      map[string][]int
+
+	// Convention columns, empty when T doesn't declare them.
+	softDelete string
+	version    string
+	createdAt  string
+	updatedAt  string
+}
+
+// NewRepository creates a repository of the table, which may be qualified
+// by a schema. It panics when T is not a struct or has no primary key,
+// since those are programming errors.
+func NewRepository[T any](db *Database, table string) *Repository[T] {
+	var resource T
+	fields := utils.Fields(resource)
+
+	r := &Repository[T]{
+		db:       db,
+		name:     table,
+		table:    quoteIdentifier(table),
+		columns:  make([]string, 0, len(fields)),
+		writable: map[string]bool{},
+		index:    map[string][]int{},
+	}
+
+	for _, field := range fields {
+		options := repoOptions(field.Tag)
+		conventions := map[string]*string{
+			RepoSoftDelete: &r.softDelete,
+			RepoVersion:    &r.version,
+			RepoCreatedAt:  &r.createdAt,
+			RepoUpdatedAt:  &r.updatedAt,
+		}
+
+		// Convention columns are maintained by the repository only.
+		writable := !options[RepoReadOnly]
+		for option, column := range conventions {
+			if options[option] {
+				*column = field.Column
+				writable = false
+			}
+		}
+
+		if options[RepoPrimaryKey] {
+			r.primaryKey = field.Column
+		}
+
+		r.writable[field.Column] = writable
+		r.index[field.Column] = field.Index
+		r.columns = append(r.columns, field.Column)
+	}
+
+	if r.primaryKey == "" {
+		for _, column := range r.columns {
+			if column == "id" {
+				r.primaryKey = column
+			}
+		}
+	}
+
+	if r.primaryKey == "" {
+		panic("repository resource " + reflect.TypeOf(resource).String() + " has no primary key")
+	}
+
+	return r
+}
+
+func repoOptions(tag reflect.StructTag) map[string]bool {
+	options := map[string]bool{}
+	for _, option := range strings.Split(tag.Get("repo"), ",") {
+		if option = strings.TrimSpace(option); option != "" {
+			options[option] = true
+		}
+	}
+
+	return options
+}
+
+// Table returns the quoted name of the table.
+func (r *Repository[T]) Table() string {
+	return r.table
+}
+
+// Columns returns the columns mapped by T.
+func (r *Repository[T]) Columns() []string {
+	return r.columns
+}
+
+// Create inserts the writable columns of the resource and scans the
+// inserted row back into it, including the values set by the database.
+// Timestamps are set to the current time and the version to 1.
+func (r *Repository[T]) Create(ctx context.Context, resource *T) error {
+	ctx = r.named(ctx, "create")
+
+	if err := r.db.Create(ctx, r.insertQuery(), resource); err != nil {
+		return errors.Propagate(err, "failed to create resource", r.ectx())
+	}
+
+	return nil
+}
+
+// Get reads the resource by its primary key. It fails with
+// KindNotFoundError when there is no such resource or it was deleted.
+func (r *Repository[T]) Get(ctx context.Context, id any) (*T, error) {
+	ctx = r.named(ctx, "get")
+
+	resource := new(T)
+	query := r.selectQuery() + r.where(quoteIdentifier(r.primaryKey)+" = $1")
+	if err := r.db.Read(ctx, query, resource, id); err != nil {
+		return nil, errors.Propagate(err, "failed to get resource", r.ectx(errors.Field("id", id)))
+	}
+
+	return resource, nil
+}
+
+// List selects the resources matching the filters extracted from filter,
+// a struct with filter tags as accepted by ExtractFilters, or nil to list
+// them all. Deleted resources are never listed. When page is nil every
+// match is returned and the result is nil.
+func (r *Repository[T]) List(ctx context.Context, filter any, page *Page) ([]*T, *PageResult, error) {
+	ctx = r.named(ctx, "list")
+
+	filters := Filters{}
+	if filter != nil {
+		filters = ExtractFilters(filter)
+	}
+
+	conditions, args := filters.Conditions()
+	query := r.selectQuery() + r.where(conditions)
+	ectx := r.ectx(errors.Field("query", query))
+
+	resources := []*T{}
+	if page == nil {
+		if err := r.db.List(ctx, query, &resources, args...); err != nil {
+			return nil, nil, errors.Propagate(err, "failed to list resources", ectx)
+		}
+
+		return resources, nil, nil
+	}
+
+	result, err := r.db.ListPage(ctx, query, &resources, page, args...)
+	if err != nil {
+		return nil, nil, errors.Propagate(err, "failed to list resources", ectx)
+	}
+
+	return resources, result, nil
+}
+
+// Update writes every writable column of the resource, found by its
+// primary key, and scans the updated row back into it. With a version
+// column, the update only succeeds when the resource still has the version
+// it was read with, failing with KindDatabaseOptimisticLockError otherwise.
+func (r *Repository[T]) Update(ctx context.Context, resource *T) error {
+	ctx = r.named(ctx, "update")
+
+	ectx := r.ectx()
+
+	query, args, err := r.updateQuery(resource)
+	if err != nil {
+		return errors.Propagate(err, "failed to build update query", ectx)
+	}
+
+	if err := r.db.updateReturning(ctx, query, resource, args...); err != nil {
+		return errors.Propagate(err, "failed to update resource", ectx)
+	}
+
+	return nil
+}
+
+// Patch updates the columns set in partial, a struct whose pointer fields
+// tagged with db are written when not nil, and returns the updated
+// resource. Without any field set, the resource is returned unchanged.
+// When partial sets the version column, it's not written but checked like
+// in Update.
+func (r *Repository[T]) Patch(ctx context.Context, id any, partial any) (*T, error) {
+	ctx = r.named(ctx, "patch")
+
+	ectx := r.ectx(errors.Field("id", id))
+
+	query, args, err := r.patchQuery(partial, id)
+	if err != nil {
+		return nil, errors.Propagate(err, "failed to build patch query", ectx)
+	}
+
+	if query == "" {
+		return r.Get(ctx, id)
+	}
+
+	resource := new(T)
+	if err := r.db.updateReturning(ctx, query, resource, args...); err != nil {
+		return nil, errors.Propagate(err, "failed to patch resource", ectx)
+	}
+
+	return resource, nil
+}
+
+// Delete removes the resource by its primary key, or marks it as deleted
+// with a soft delete column. It fails with KindNotFoundError when there is
+// no such resource or it was already deleted.
+func (r *Repository[T]) Delete(ctx context.Context, id any) error {
+	ctx = r.named(ctx, "delete")
+
+	ectx := r.ectx(errors.Field("id", id))
+	pk := quoteIdentifier(r.primaryKey) + " = $1"
+
+	if r.softDelete == "" {
+		if err := r.db.Delete(ctx, "DELETE FROM "+r.table+" WHERE "+pk, id); err != nil {
+			return errors.Propagate(err, "failed to delete resource", ectx)
+		}
+
+		return nil
+	}
+
+	assignments := append([]string{quoteIdentifier(r.softDelete) + " = now()"}, r.touch()...)
+	query := "UPDATE " + r.table + " SET " + strings.Join(assignments, ", ") + r.where(pk)
+	if err := r.db.Update(ctx, query, id); err != nil {
+		return errors.Propagate(err, "failed to delete resource", ectx)
+	}
+
+	return nil
+}
+
+// Restore brings back a soft deleted resource and returns it. It fails
+// with KindNotFoundError when there is no such deleted resource.
+func (r *Repository[T]) Restore(ctx context.Context, id any) (*T, error) {
+	ctx = r.named(ctx, "restore")
+
+	ectx := r.ectx(errors.Field("id", id))
+
+	if r.softDelete == "" {
+		return nil, errors.New(errors.KindSystemError, "repository resource has no soft delete column", ectx)
+	}
+
+	assignments := append([]string{quoteIdentifier(r.softDelete) + " = NULL"}, r.touch()...)
+	query := "UPDATE " + r.table + " SET " + strings.Join(assignments, ", ") +
+		" WHERE " + quoteIdentifier(r.primaryKey) + " = $1 AND " + quoteIdentifier(r.softDelete) + " IS NOT NULL" +
+		" RETURNING " + ColumnList(r.columns...)
+
+	resource := new(T)
+	if err := r.db.updateReturning(ctx, query, resource, id); err != nil {
+		return nil, errors.Propagate(err, "failed to restore resource", ectx)
+	}
+
+	return resource, nil
+}
+
+func (r *Repository[T]) selectQuery() string {
+	return "SELECT " + ColumnList(r.columns...) + " FROM " + r.table
+}
+
+func (r *Repository[T]) insertQuery() string {
+	columns := []string{}
+	values := []string{}
+	for _, column := range r.columns {
+		value := ":" + column
+		switch {
+		case column == r.createdAt || column == r.updatedAt:
+			value = "now()"
+		case column == r.version:
+			value = "1"
+		case !r.writable[column]:
+			continue
+		}
+
+		columns = append(columns, quoteIdentifier(column))
+		values = append(values, value)
+	}
+
+	return "INSERT INTO " + r.table +
+		" (" + strings.Join(columns, ", ") + ") VALUES (" + strings.Join(values, ", ") + ")" +
+		" RETURNING " + ColumnList(r.columns...)
+}
+
+// named names the queries of the method after the table, as in
+// users.get, unless the caller named them.
+func (r *Repository[T]) named(ctx context.Context, method string) context.Context {
+	return withDefaultQueryName(ctx, r.name+"."+method)
+}
+
+// where builds a WHERE clause of the conditions, hiding deleted resources.
+func (r *Repository[T]) where(conditions ...string) string {
+	if r.softDelete != "" {
+		conditions = append(conditions, quoteIdentifier(r.softDelete)+" IS NULL")
+	}
+
+	nonEmpty := make([]string, 0, len(conditions))
+	for _, condition := range conditions {
+		if condition != "" {
+			nonEmpty = append(nonEmpty, condition)
+		}
+	}
+
+	if len(nonEmpty) == 0 {
+		return ""
+	}
+
+	return " WHERE " + strings.Join(nonEmpty, " AND ")
+}
+
+// touch lists the assignments maintaining the convention columns on every
+// change of a resource.
+func (r *Repository[T]) touch() []string {
+	assignments := []string{}
+	if r.updatedAt != "" {
+		assignments = append(assignments, quoteIdentifier(r.updatedAt)+" = now()")
+	}
+	if r.version != "" {
+		assignments = append(assignments, quoteIdentifier(r.version)+" = "+quoteIdentifier(r.version)+" + 1")
+	}
+
+	return assignments
+}
+
+// updateQuery builds the update of every writable column of the resource,
+// guarded by its version, if any.
+func (r *Repository[T]) updateQuery(resource *T) (string, []any, error) {
+	value := reflect.ValueOf(resource).Elem()
+
+	assignments := []string{}
+	for _, column := range r.columns {
+		if r.writable[column] && column != r.primaryKey {
+			assignments = append(assignments, quoteIdentifier(column)+" = :"+column)
+		}
+	}
+
+	var expected *Version
+	if r.version != "" {
+		version, err := toVersion(value.FieldByIndex(r.index[r.version]).Interface())
+		if err != nil {
+			return "", nil, err
+		}
+		expected = &version
+	}
+
+	query, args, err := bindNamed("UPDATE "+r.table+" SET "+strings.Join(append(assignments, r.touch()...), ", "), resource)
+	if err != nil {
+		return "", nil, err
+	}
+	query, args = r.guard(query, args, value.FieldByIndex(r.index[r.primaryKey]).Interface(), expected)
+
+	return query, args, nil
+}
+
+// patchQuery builds the update of the columns set in partial, or an empty
+// query when there is none. The primary key is bound after the values.
+func (r *Repository[T]) patchQuery(partial any, id any) (string, []any, error) {
+	assignments := []string{}
+	var expected *Version
+
+	for column, value := range utils.ResourceIter(partial) {
+		if column == r.version && r.version != "" {
+			version, err := toVersion(value)
+			if err != nil {
+				return "", nil, err
+			}
+			expected = &version
+			continue
+		}
+
+		if !r.writable[column] || column == r.primaryKey {
+			return "", nil, errors.New(
+				errors.KindSystemError,
+				"column can't be patched",
+				errors.Context(
+					errors.Field("column", column),
+					errors.Field("table", r.table),
+				),
+			)
+		}
+
+		assignments = append(assignments, quoteIdentifier(column)+" = :"+column)
+	}
+
+	if len(assignments) == 0 {
+		return "", nil, nil
+	}
+
+	query, args, err := bindNamed("UPDATE "+r.table+" SET "+strings.Join(append(assignments, r.touch()...), ", "), partial)
+	if err != nil {
+		return "", nil, err
+	}
+	query, args = r.guard(query, args, id, expected)
+
+	return query, args, nil
+}
+
+// bindNamed binds the named parameters of the query to the fields of arg
+// as postgres placeholders. Unlike utils.Named, slices are bound as they
+// are, since they're array or JSON column values rather than lists.
+func bindNamed(query string, arg any) (string, []any, error) {
+	query, args, err := sqlx.Named(query, arg)
+	if err != nil {
+		return "", nil, errors.PropagateAs(errors.KindSystemError, err, "failed to bind named query", errors.Context(
+			errors.Field("query", query),
+		))
+	}
+
+	return sqlx.Rebind(sqlx.DOLLAR, query), args, nil
+}
+
+// guard restricts an update to the resource with the primary key and, if
+// given, the expected version, returning the updated row.
+func (r *Repository[T]) guard(query string, args []any, id any, expected *Version) (string, []any) {
+	args = append(args, id)
+	conditions := []string{quoteIdentifier(r.primaryKey) + " = $" + strconv.Itoa(len(args))}
+
+	if expected != nil {
+		args = append(args, *expected)
+		conditions = append(conditions, quoteIdentifier(r.version)+" = $"+strconv.Itoa(len(args)))
+	}
+
+	return query + r.where(conditions...) + " RETURNING " + ColumnList(r.columns...), args
+}
+
+func toVersion(value any) (Version, error) {
+	v := reflect.Indirect(reflect.ValueOf(value))
+	switch {
+	case v.CanInt():
+		return Version(v.Int()), nil
+	case v.CanUint():
+		return Version(v.Uint()), nil
+	}
+
+	return 0, errors.New(
+		errors.KindSystemError,
+		"version column must be an integer",
+		errors.Context(errors.Field("type", fmt.Sprintf("%T", value))),
+	)
+}
+
+func (r *Repository[T]) ectx(entries ...errors.Entry) *errors.ContextT {
+	return errors.Context(append([]errors.Entry{errors.Field("table", r.table)}, entries...)...)
+}
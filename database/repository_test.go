@@ -0,0 +1,173 @@
+//go:build unit
+// +build unit
+
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dexlabsio/garlic/errors"
+)
+
+type repoTimestamps struct {
+	CreatedAt time.Time `db:"created_at" repo:"readonly"`
+}
+
+type repoUser struct {
+	Id    int    `db:"id" repo:"pk,readonly"`
+	Name  string `db:"name"`
+	Email string `db:"email"`
+	repoTimestamps
+}
+
+type repoUserPatch struct {
+	Name  *string `db:"name"`
+	Email *string `db:"email"`
+}
+
+func TestRepositoryQueries(t *testing.T) {
+	repo := NewRepository[repoUser](&Database{}, "auth.users")
+
+	assert.Equal(t, `"auth"."users"`, repo.Table())
+	assert.Equal(t, []string{"id", "name", "email", "created_at"}, repo.Columns())
+
+	assert.Equal(t,
+		`INSERT INTO "auth"."users" ("name", "email") VALUES (:name, :email) RETURNING "id", "name", "email", "created_at"`,
+		repo.insertQuery(),
+	)
+
+	name := "John"
+	query, args, err := repo.patchQuery(&repoUserPatch{Name: &name}, 7)
+	assert.NoError(t, err)
+	assert.Equal(t, `UPDATE "auth"."users" SET "name" = $1 WHERE "id" = $2 RETURNING "id", "name", "email", "created_at"`, query)
+	assert.Equal(t, []any{&name, 7}, args)
+
+	query, _, err = repo.patchQuery(&repoUserPatch{}, 7)
+	assert.NoError(t, err)
+	assert.Empty(t, query)
+
+	id := 8
+	_, _, err = repo.patchQuery(&struct {
+		Id *int `db:"id"`
+	}{Id: &id}, 7)
+	assert.Error(t, err)
+}
+
+func TestRepositorySliceColumns(t *testing.T) {
+	type article struct {
+		Id   int      `db:"id"`
+		Tags []string `db:"tags"`
+	}
+
+	type articlePatch struct {
+		Tags *[]string `db:"tags"`
+	}
+
+	repo := NewRepository[article](&Database{}, "articles")
+	tags := []string{"go", "sql"}
+
+	query, args, err := repo.updateQuery(&article{Id: 7, Tags: tags})
+	assert.NoError(t, err)
+	assert.Equal(t, `UPDATE "articles" SET "tags" = $1 WHERE "id" = $2 RETURNING "id", "tags"`, query)
+	assert.Equal(t, []any{tags, 7}, args)
+
+	query, args, err = repo.patchQuery(&articlePatch{Tags: &tags}, 7)
+	assert.NoError(t, err)
+	assert.Equal(t, `UPDATE "articles" SET "tags" = $1 WHERE "id" = $2 RETURNING "id", "tags"`, query)
+	assert.Equal(t, []any{&tags, 7}, args)
+}
+
+func TestRepositoryPrimaryKey(t *testing.T) {
+	assert.Panics(t, func() {
+		NewRepository[struct {
+			Name string `db:"name"`
+		}](&Database{}, "names")
+	})
+}
+
+func TestRepositoryDelete(t *testing.T) {
+	rec, sqlDB := newRecorder()
+	db := &Database{config: Defaults(), DB: sqlDB}
+	repo := NewRepository[repoUser](db, "users")
+
+	err := NewStorer(db).Transaction(context.Background(), func(ctx context.Context) error {
+		return repo.Delete(ctx, 7)
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"BEGIN", `DELETE FROM "users" WHERE "id" = $1`, "COMMIT"}, rec.Statements())
+}
+
+type repoDocument struct {
+	Id        string     `db:"id" repo:"pk"`
+	Title     string     `db:"title"`
+	Version   int        `db:"version" repo:"version"`
+	CreatedAt time.Time  `db:"created_at" repo:"created_at"`
+	UpdatedAt time.Time  `db:"updated_at" repo:"updated_at"`
+	DeletedAt *time.Time `db:"deleted_at" repo:"soft_delete"`
+}
+
+type repoDocumentPatch struct {
+	Title   *string `db:"title"`
+	Version *int    `db:"version"`
+}
+
+func TestRepositoryConventions(t *testing.T) {
+	repo := NewRepository[repoDocument](&Database{}, "documents")
+	columns := `"id", "title", "version", "created_at", "updated_at", "deleted_at"`
+
+	assert.Equal(t,
+		`INSERT INTO "documents" ("id", "title", "version", "created_at", "updated_at") VALUES (:id, :title, 1, now(), now()) RETURNING `+columns,
+		repo.insertQuery(),
+	)
+
+	assert.Equal(t, ` WHERE "id" = $1 AND "deleted_at" IS NULL`, repo.where(`"id" = $1`))
+
+	title, version := "Draft", 3
+	query, args, err := repo.patchQuery(&repoDocumentPatch{Title: &title, Version: &version}, "doc")
+	assert.NoError(t, err)
+	assert.Equal(t,
+		`UPDATE "documents" SET "title" = $1, "updated_at" = now(), "version" = "version" + 1 WHERE "id" = $2 AND "version" = $3 AND "deleted_at" IS NULL RETURNING `+columns,
+		query,
+	)
+	assert.Equal(t, []any{&title, "doc", Version(3)}, args)
+
+	query, args, err = repo.updateQuery(&repoDocument{Id: "doc", Title: "Final", Version: 4})
+	assert.NoError(t, err)
+	assert.Equal(t,
+		`UPDATE "documents" SET "title" = $1, "updated_at" = now(), "version" = "version" + 1 WHERE "id" = $2 AND "version" = $3 AND "deleted_at" IS NULL RETURNING `+columns,
+		query,
+	)
+	assert.Equal(t, []any{"Final", "doc", Version(4)}, args)
+}
+
+func TestRepositorySoftDelete(t *testing.T) {
+	rec, sqlDB := newRecorder()
+	repo := NewRepository[repoDocument](&Database{config: Defaults(), DB: sqlDB}, "documents")
+
+	assert.NoError(t, repo.Delete(context.Background(), "doc"))
+	assert.Equal(t, []string{
+		`UPDATE "documents" SET "deleted_at" = now(), "updated_at" = now(), "version" = "version" + 1 WHERE "id" = $1 AND "deleted_at" IS NULL`,
+	}, rec.Statements())
+
+	_, err := NewRepository[repoUser](&Database{}, "users").Restore(context.Background(), 1)
+	assert.True(t, errors.IsKind(err, errors.KindSystemError))
+}
+
+func TestOptimisticLock(t *testing.T) {
+	rec, sqlDB := newRecorder()
+	rec.affected = func(string) int64 { return 0 }
+	db := &Database{config: Defaults(), DB: sqlDB}
+
+	query := "UPDATE documents SET title = $1 WHERE id = $2 AND version = $3"
+	err := db.Update(context.Background(), query, "Final", "doc", Version(2))
+	assert.True(t, errors.IsKind(err, KindDatabaseOptimisticLockError))
+	assert.Equal(t, 409, errors.KindDatabaseOptimisticLockError.StatusCode())
+
+	err = db.Update(context.Background(), "UPDATE documents SET title = $1 WHERE id = $2", "Final", "doc")
+	assert.True(t, errors.IsKind(err, errors.KindNotFoundError))
+}
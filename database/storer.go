@@ -3,18 +3,27 @@ package database
 import (
 	"context"
 	"database/sql"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"go.uber.org/zap"
 
 	"github.com/dexlabsio/garlic/errors"
 	"github.com/dexlabsio/garlic/logging"
+	"github.com/dexlabsio/garlic/monitoring"
 )
 
 type Store interface {
-	BeginContext(ctx context.Context) (ctxTx context.Context, commit, rollback func() error, err error)
+	BeginContext(ctx context.Context, opts ...TxOption) (ctxTx context.Context, commit, rollback func() error, err error)
 	Create(ctx context.Context, query string, resource any) error
+	CreateMany(ctx context.Context, query string, resources any) error
+	Upsert(ctx context.Context, query string, resource any, conflict *OnConflict) error
+	CopyFrom(ctx context.Context, table string, columns []string, resources any) (int64, error)
 	Read(ctx context.Context, query string, resource any, args ...any) error
 	Update(ctx context.Context, query string, args ...any) error
 	Delete(ctx context.Context, query string, args ...any) error
 	List(ctx context.Context, query string, resourceList any, args ...any) error
+	ListPage(ctx context.Context, query string, resourceList any, page *Page, args ...any) (*PageResult, error)
 	RawExec(ctx context.Context, query string, args ...any) (sql.Result, error)
 	NamedRawExec(ctx context.Context, query string, resource any) (sql.Result, error)
 }
@@ -27,10 +36,59 @@ func NewStorer(store Store) *Storer {
 	return &Storer{Store: store}
 }
 
-func (s *Storer) Transaction(ctx context.Context, fn func(context.Context) error) error {
+// Transaction runs fn in a transaction, committed when fn succeeds and
+// rolled back when it fails or panics. Nested calls run in savepoints of the
+// outer transaction, so a failed inner unit of work is rolled back alone and
+// the outer function may handle its error and carry on.
+//
+// With the MaxRetries option, when the outermost transaction fails with a
+// serialization failure or a deadlock, the whole function is retried with
+// backoff up to the given times, so fn must be safe to run more than once.
+// Without it, such errors are returned as they are. Nested calls are never
+// retried, since the outer transaction is aborted by such errors.
+func (s *Storer) Transaction(ctx context.Context, fn func(context.Context) error, opts ...TxOption) error {
+	options := NewTxOptions(opts...)
+	if Transaction(ctx) != nil || options.MaxRetries <= 0 {
+		return s.transaction(ctx, fn, opts)
+	}
+
+	operation := func() error {
+		err := s.transaction(ctx, fn, opts)
+		if _, retryable := RetryableCode(err); err != nil && !retryable {
+			return backoff.Permanent(err)
+		}
+
+		return err
+	}
+
+	attempt := 0
+	notify := func(err error, delay time.Duration) {
+		attempt++
+		code, _ := RetryableCode(err)
+		monitoring.IncrementTransactionRetries(code)
+		logging.GetLoggerFromContextOrGlobal(ctx).Warn(
+			"Retrying database transaction",
+			zap.Int("attempt", attempt),
+			zap.String("code", code),
+			zap.Duration("delay", delay),
+			errors.Zap(err),
+		)
+	}
+
+	expBackoff := backoff.NewExponentialBackOff()
+	expBackoff.InitialInterval = 20 * time.Millisecond
+	expBackoff.MaxInterval = time.Second
+	expBackoff.MaxElapsedTime = 0 // Retries are limited by MaxRetries
+
+	policy := backoff.WithContext(backoff.WithMaxRetries(expBackoff, uint64(options.MaxRetries)), ctx)
+	return backoff.RetryNotify(operation, policy, notify)
+}
+
+// transaction runs a single attempt of Transaction.
+func (s *Storer) transaction(ctx context.Context, fn func(context.Context) error, opts []TxOption) error {
 	var err error
 
-	ctxTx, commit, rollback, err := s.Store.BeginContext(ctx)
+	ctxTx, commit, rollback, err := s.Store.BeginContext(ctx, opts...)
 	if err != nil {
 		return errors.Propagate(err, "storer failed to begin database transaction")
 	}
@@ -2,6 +2,8 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"strconv"
 
 	"github.com/dexlabsio/garlic/errors"
 	"github.com/jmoiron/sqlx"
@@ -11,19 +13,37 @@ type key int
 
 const (
 	TransactionKey key = iota
+	SavepointDepthKey
+	ConnectionKey
+	ReadYourWritesKey
+	QueryNameKey
 )
 
 // BeginContext starts a new database transaction within the provided context.
 // It returns a new context containing the transaction, along with commit and rollback functions.
-// If a transaction already exists in the context, it returns the existing context and no-op functions.
-// If starting the transaction fails, it returns an error wrapped with KindDatabaseTransactionError.
-func BeginContext(ctx context.Context, db *sqlx.DB) (ctxTx context.Context, commit, rollback func() error, err error) {
-	if Transaction(ctx) != nil {
-		return ctx, Nop(), Nop(), nil
+// If a transaction already exists in the context, it creates a savepoint instead, see BeginSavepoint,
+// and the options are ignored. If starting the transaction fails, it returns an error wrapped with
+// KindDatabaseTransactionError.
+func BeginContext(ctx context.Context, db *sqlx.DB, opts ...TxOption) (ctxTx context.Context, commit, rollback func() error, err error) {
+	if tx := Transaction(ctx); tx != nil {
+		return BeginSavepoint(ctx, tx)
 	}
 
-	tx, err := db.BeginTxx(ctx, nil)
+	// The transaction runs on a dedicated connection, kept in the context so
+	// native driver features, such as COPY, can take part in it.
+	conn, err := db.Connx(ctx)
 	if err != nil {
+		return ctx, Nop(), Nop(), TranslateError(
+			KindDatabaseTransactionError,
+			err,
+			"failed to acquire connection for transaction",
+		)
+	}
+
+	options := NewTxOptions(opts...)
+	tx, err := conn.BeginTxx(ctx, &sql.TxOptions{Isolation: options.Isolation, ReadOnly: options.ReadOnly})
+	if err != nil {
+		_ = conn.Close()
 		return ctx, Nop(), Nop(), errors.PropagateAs(
 			KindDatabaseTransactionError,
 			err,
@@ -31,18 +51,43 @@ func BeginContext(ctx context.Context, db *sqlx.DB) (ctxTx context.Context, comm
 		)
 	}
 
+	if options.Deferrable {
+		// database/sql has no deferrable option, but postgres accepts it
+		// before the first query of the transaction.
+		if _, err := tx.ExecContext(ctx, "SET TRANSACTION DEFERRABLE"); err != nil {
+			_ = tx.Rollback()
+			_ = conn.Close()
+			return ctx, Nop(), Nop(), errors.PropagateAs(
+				KindDatabaseTransactionError,
+				err,
+				"failed to set transaction as deferrable",
+			)
+		}
+	}
+
 	ctxTx = context.WithValue(ctx, TransactionKey, tx)
-	commit = Commit(tx)
-	rollback = Rollback(tx)
+	ctxTx = context.WithValue(ctxTx, ConnectionKey, conn)
+
+	// The connection goes back to the pool once the transaction is done.
+	commit = func() error {
+		defer conn.Close()
+		return Commit(tx)()
+	}
+	rollback = func() error {
+		defer conn.Close()
+		return Rollback(tx)()
+	}
 	return
 }
 
 // Rollback attempts to roll back the given transaction. If the rollback
 // operation fails, it returns an error wrapped with KindDatabaseTransactionError.
-// Otherwise, it returns nil, indicating the rollback was successful.
+// Otherwise, it returns nil, indicating the rollback was successful. A
+// transaction already done, like one whose commit failed, has nothing left
+// to roll back, so it's not an error.
 func Rollback(tx *sqlx.Tx) func() error {
 	return func() error {
-		if err := tx.Rollback(); err != nil {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
 			return errors.PropagateAs(
 				KindDatabaseTransactionError,
 				err,
@@ -61,7 +106,9 @@ func Rollback(tx *sqlx.Tx) func() error {
 func Commit(tx *sqlx.Tx) func() error {
 	return func() error {
 		if err := tx.Commit(); err != nil {
-			return errors.PropagateAs(
+			// Serializable transactions may fail on commit, which is
+			// translated so Storer.Transaction can retry it.
+			return TranslateError(
 				KindDatabaseTransactionError,
 				err,
 				"failed to commit transaction",
@@ -72,6 +119,63 @@ func Commit(tx *sqlx.Tx) func() error {
 	}
 }
 
+// BeginSavepoint starts a nested unit of work inside the transaction of the
+// context. Commit releases the savepoint, keeping its changes pending on the
+// outer transaction, while rollback undoes only the changes made since the
+// savepoint, so the outer transaction can carry on.
+func BeginSavepoint(ctx context.Context, tx *sqlx.Tx) (ctxTx context.Context, commit, rollback func() error, err error) {
+	depth := SavepointDepth(ctx) + 1
+	name := "garlic_sp_" + strconv.Itoa(depth)
+
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return ctx, Nop(), Nop(), errors.PropagateAs(
+			KindDatabaseTransactionError,
+			err,
+			"failed to create savepoint",
+			errors.Context(errors.Field("savepoint", name)),
+		)
+	}
+
+	ctxTx = context.WithValue(ctx, SavepointDepthKey, depth)
+
+	commit = func() error {
+		if _, err := tx.Exec("RELEASE SAVEPOINT " + name); err != nil {
+			return TranslateError(
+				KindDatabaseTransactionError,
+				err,
+				"failed to release savepoint",
+				errors.Context(errors.Field("savepoint", name)),
+			)
+		}
+
+		return nil
+	}
+
+	rollback = func() error {
+		// Rolling back keeps the savepoint, which is released right after so
+		// the next unit of work at this depth can reuse its name.
+		if _, err := tx.Exec("ROLLBACK TO SAVEPOINT " + name + "; RELEASE SAVEPOINT " + name); err != nil {
+			return errors.PropagateAs(
+				KindDatabaseTransactionError,
+				err,
+				"failed to rollback to savepoint",
+				errors.Context(errors.Field("savepoint", name)),
+			)
+		}
+
+		return nil
+	}
+
+	return
+}
+
+// SavepointDepth returns how many savepoints are nested in the transaction
+// of the context, zero for the outermost transaction or without one.
+func SavepointDepth(ctx context.Context) int {
+	depth, _ := ctx.Value(SavepointDepthKey).(int)
+	return depth
+}
+
 // Transaction retrieves the current database transaction from the provided context.
 // If no transaction is found in the context, it returns nil. This function is useful
 // for checking whether a transaction is already active within a given context.
@@ -84,6 +188,17 @@ func Transaction(ctx context.Context) *sqlx.Tx {
 	return tx
 }
 
+// Connection retrieves the connection of the current database transaction
+// from the provided context, or nil when no transaction is active.
+func Connection(ctx context.Context) *sqlx.Conn {
+	conn, ok := ctx.Value(ConnectionKey).(*sqlx.Conn)
+	if !ok {
+		return nil
+	}
+
+	return conn
+}
+
 // Nop returns a no-operation function that always returns nil.
 // This is useful as a placeholder for commit or rollback functions
 // when no actual operation is needed, such as when a transaction
@@ -0,0 +1,137 @@
+//go:build unit
+// +build unit
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dexlabsio/garlic/errors"
+)
+
+func TestNestedTransactions(t *testing.T) {
+	rec, sqlDB := newRecorder()
+	storer := NewStorer(&Database{config: Defaults(), DB: sqlDB})
+
+	err := storer.Transaction(context.Background(), func(ctx context.Context) error {
+		if _, err := storer.Store.RawExec(ctx, "INSERT 1"); err != nil {
+			return err
+		}
+
+		// A failed inner unit of work is rolled back on its own.
+		err := storer.Transaction(ctx, func(ctx context.Context) error {
+			assert.Equal(t, 1, SavepointDepth(ctx))
+			if _, err := storer.Store.RawExec(ctx, "INSERT 2"); err != nil {
+				return err
+			}
+			return errors.New(errors.KindUserError, "inner failure")
+		})
+		assert.Error(t, err)
+
+		return storer.Transaction(ctx, func(ctx context.Context) error {
+			return storer.Transaction(ctx, func(ctx context.Context) error {
+				assert.Equal(t, 2, SavepointDepth(ctx))
+				_, err := storer.Store.RawExec(ctx, "INSERT 3")
+				return err
+			})
+		})
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"BEGIN",
+		"INSERT 1",
+		"SAVEPOINT garlic_sp_1",
+		"INSERT 2",
+		"ROLLBACK TO SAVEPOINT garlic_sp_1; RELEASE SAVEPOINT garlic_sp_1",
+		"SAVEPOINT garlic_sp_1",
+		"SAVEPOINT garlic_sp_2",
+		"INSERT 3",
+		"RELEASE SAVEPOINT garlic_sp_2",
+		"RELEASE SAVEPOINT garlic_sp_1",
+		"COMMIT",
+	}, rec.Statements())
+}
+
+func TestTransactionRetry(t *testing.T) {
+	rec, sqlDB := newRecorder()
+	storer := NewStorer(&Database{config: Defaults(), DB: sqlDB})
+
+	failures := 2
+	rec.fail = func(query string) error {
+		if query == "UPDATE" && failures > 0 {
+			failures--
+			return &pgconn.PgError{Code: SerializationFailureCode}
+		}
+		return nil
+	}
+
+	calls := 0
+	err := storer.Transaction(context.Background(), func(ctx context.Context) error {
+		calls++
+		_, err := storer.Store.RawExec(ctx, "UPDATE")
+		return err
+	}, Isolation(sql.LevelSerializable), ReadOnly(), Deferrable(), MaxRetries(3))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+
+	attempt := []string{"BEGIN ISOLATION LEVEL SERIALIZABLE READ ONLY", "SET TRANSACTION DEFERRABLE", "UPDATE"}
+	expected := append(append(append(append([]string{}, attempt...), "ROLLBACK"), attempt...), "ROLLBACK")
+	expected = append(append(expected, attempt...), "COMMIT")
+	assert.Equal(t, expected, rec.Statements())
+
+	// Other errors and exhausted retries are returned as they are.
+	failures = 10
+	calls = 0
+	err = storer.Transaction(context.Background(), func(ctx context.Context) error {
+		calls++
+		_, err := storer.Store.RawExec(ctx, "UPDATE")
+		return err
+	}, MaxRetries(1))
+	_, retryable := RetryableCode(err)
+	assert.True(t, retryable)
+	assert.Equal(t, 2, calls)
+
+	// Transactions aren't retried unless opted in.
+	calls = 0
+	err = storer.Transaction(context.Background(), func(ctx context.Context) error {
+		calls++
+		_, err := storer.Store.RawExec(ctx, "UPDATE")
+		return err
+	})
+	_, retryable = RetryableCode(err)
+	assert.True(t, retryable)
+	assert.Equal(t, 1, calls)
+
+	calls = 0
+	err = storer.Transaction(context.Background(), func(ctx context.Context) error {
+		calls++
+		return errors.New(errors.KindUserError, "not retryable")
+	}, MaxRetries(3))
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestRollbackAfterFailedCommit(t *testing.T) {
+	rec, sqlDB := newRecorder()
+	rec.fail = func(query string) error {
+		if query == "COMMIT" {
+			return &pgconn.PgError{Code: SerializationFailureCode}
+		}
+		return nil
+	}
+
+	_, commit, rollback, err := BeginContext(context.Background(), sqlDB)
+	assert.NoError(t, err)
+
+	// Storer.Transaction rolls back when the commit fails, which finds the
+	// transaction already done.
+	assert.Error(t, commit())
+	assert.NoError(t, rollback())
+	assert.Equal(t, []string{"BEGIN", "COMMIT"}, rec.Statements())
+}
@@ -0,0 +1,150 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"regexp"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/dexlabsio/garlic/errors"
+)
+
+// keyColumnsReg extracts the column names of the key reported in the
+// detail of unique, foreign key and exclusion violations, such as
+// `Key (org_id, email)=(...) already exists.`. The values are not exposed.
+var keyColumnsReg = regexp.MustCompile(`^Key \(([^)]*)\)=`)
+
+// TranslateError converts an error returned by the driver into a garlic
+// error of the matching kind, with a message and a hint meant for users and
+// public details such as the violated constraint and columns. Errors that
+// can't be translated are propagated with the given kind and message.
+func TranslateError(kind *errors.Kind, err error, message string, opts ...errors.Opt) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return errors.PropagateAs(KindDatabaseQueryCanceledError, err, "database query was cancelled", opts...)
+	}
+
+	var connErr *pgconn.ConnectError
+	if errors.As(err, &connErr) || errors.Is(err, driver.ErrBadConn) {
+		return errors.PropagateAs(KindDatabaseConnectionError, err, "failed to connect to the database", opts...)
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return errors.PropagateAs(kind, err, message, opts...)
+	}
+
+	opts = append(opts, errors.Context(
+		errors.Field("pg_code", pgErr.Code),
+		errors.Field("pg_message", pgErr.Message),
+		errors.Field("pg_detail", pgErr.Detail),
+	))
+
+	switch pgErr.Code {
+	case "23505": // unique_violation
+		opts = append(opts, constraintDetails(pgErr)...)
+		return errors.PropagateAs(
+			KindDatabaseUniqueViolationError,
+			err,
+			"resource already exists",
+			append(opts, errors.Hint(
+				"Another resource with similar parameters already exists in our system. "+
+					"Please change the parameters and try again.",
+			))...,
+		)
+
+	case "23503": // foreign_key_violation
+		opts = append(opts, constraintDetails(pgErr)...)
+		return errors.PropagateAs(
+			KindDatabaseForeignKeyViolationError,
+			err,
+			"resource reference conflict",
+			append(opts, errors.Hint(
+				"The resource references another resource that doesn't exist, "+
+					"or is still referenced by other resources. Please check the references and try again.",
+			))...,
+		)
+
+	case "23P01": // exclusion_violation
+		opts = append(opts, constraintDetails(pgErr)...)
+		return errors.PropagateAs(
+			KindDatabaseExclusionViolationError,
+			err,
+			"resource conflicts with an existing resource",
+			append(opts, errors.Hint("Please change the parameters that overlap with the existing resource and try again."))...,
+		)
+
+	case "23514": // check_violation
+		opts = append(opts, constraintDetails(pgErr)...)
+		return errors.PropagateAs(
+			KindDatabaseCheckViolationError,
+			err,
+			"invalid field value",
+			append(opts, errors.Hint("Some field has a value that is not allowed. Please check the documentation and try again."))...,
+		)
+
+	case "23502": // not_null_violation
+		opts = append(opts, constraintDetails(pgErr)...)
+		return errors.PropagateAs(
+			KindDatabaseNotNullViolationError,
+			err,
+			"missing required field",
+			append(opts, errors.Hint(
+				"Some required parameters are missing. "+
+					"Please, check the documentation to clarify which parameters are necessary and try again.",
+			))...,
+		)
+
+	case SerializationFailureCode:
+		return errors.PropagateAs(KindDatabaseSerializationError, err, "transaction serialization failure", opts...)
+
+	case DeadlockDetectedCode:
+		return errors.PropagateAs(KindDatabaseDeadlockError, err, "transaction deadlock detected", opts...)
+
+	case "55P03": // lock_not_available
+		return errors.PropagateAs(KindDatabaseLockTimeoutError, err, "timeout waiting for a database lock", opts...)
+
+	case "57014": // query_canceled, including statement timeouts
+		return errors.PropagateAs(KindDatabaseQueryCanceledError, err, "database query was cancelled", opts...)
+
+	case "57P01", "57P02", "57P03", "53300": // shutdowns and too_many_connections
+		return errors.PropagateAs(KindDatabaseConnectionError, err, "database is unavailable", opts...)
+	}
+
+	if strings.HasPrefix(pgErr.Code, "08") { // connection_exception class
+		return errors.PropagateAs(KindDatabaseConnectionError, err, "database connection failure", opts...)
+	}
+
+	return errors.PropagateAs(kind, err, message, opts...)
+}
+
+// constraintDetails exposes which constraint, table and columns were
+// violated, without exposing the offending values.
+func constraintDetails(pgErr *pgconn.PgError) []errors.Opt {
+	opts := []errors.Opt{}
+
+	if pgErr.ConstraintName != "" {
+		opts = append(opts, errors.Detail("constraint", pgErr.ConstraintName))
+	}
+
+	if pgErr.TableName != "" {
+		opts = append(opts, errors.Detail("table", pgErr.TableName))
+	}
+
+	if pgErr.ColumnName != "" {
+		opts = append(opts, errors.Detail("columns", []string{pgErr.ColumnName}))
+	} else if match := keyColumnsReg.FindStringSubmatch(pgErr.Detail); match != nil {
+		columns := strings.Split(match[1], ",")
+		for i := range columns {
+			columns[i] = strings.Trim(strings.TrimSpace(columns[i]), `"`)
+		}
+		opts = append(opts, errors.Detail("columns", columns))
+	}
+
+	return opts
+}
@@ -0,0 +1,64 @@
+//go:build unit
+// +build unit
+
+package database
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dexlabsio/garlic/errors"
+)
+
+func TestTranslateError(t *testing.T) {
+	cases := []struct {
+		err    error
+		kind   *errors.Kind
+		status int
+	}{
+		{&pgconn.PgError{Code: "23505"}, KindDatabaseUniqueViolationError, http.StatusConflict},
+		{&pgconn.PgError{Code: "23503"}, KindDatabaseForeignKeyViolationError, http.StatusConflict},
+		{&pgconn.PgError{Code: "23P01"}, KindDatabaseExclusionViolationError, http.StatusConflict},
+		{&pgconn.PgError{Code: "23514"}, KindDatabaseCheckViolationError, http.StatusBadRequest},
+		{&pgconn.PgError{Code: "23502"}, KindDatabaseNotNullViolationError, http.StatusBadRequest},
+		{&pgconn.PgError{Code: "40001"}, KindDatabaseSerializationError, http.StatusInternalServerError},
+		{&pgconn.PgError{Code: "40P01"}, KindDatabaseDeadlockError, http.StatusInternalServerError},
+		{&pgconn.PgError{Code: "55P03"}, KindDatabaseLockTimeoutError, http.StatusInternalServerError},
+		{&pgconn.PgError{Code: "57014"}, KindDatabaseQueryCanceledError, http.StatusInternalServerError},
+		{&pgconn.PgError{Code: "08006"}, KindDatabaseConnectionError, http.StatusServiceUnavailable},
+		{fmt.Errorf("query: %w", context.Canceled), KindDatabaseQueryCanceledError, http.StatusInternalServerError},
+		{&pgconn.PgError{Code: "42601"}, errors.KindSystemError, http.StatusInternalServerError},
+	}
+
+	for _, c := range cases {
+		err := TranslateError(errors.KindSystemError, c.err, "fallback")
+		e, ok := err.(*errors.ErrorT)
+		assert.True(t, ok)
+		assert.Equal(t, c.kind, e.Kind(), c.err.Error())
+		assert.Equal(t, c.status, e.Kind().StatusCode(), c.err.Error())
+	}
+}
+
+func TestTranslateErrorDetails(t *testing.T) {
+	err := TranslateError(errors.KindSystemError, &pgconn.PgError{
+		Code:           "23505",
+		ConstraintName: "users_org_email_key",
+		TableName:      "users",
+		Detail:         `Key (org_id, "email")=(1, john@doe.com) already exists.`,
+	}, "failed to insert resource")
+
+	dto := err.(*errors.ErrorT).ErrorDTO()
+	assert.Equal(t, "resource already exists", dto.Error)
+	assert.Equal(t, "users_org_email_key", dto.Details["constraint"])
+	assert.Equal(t, "users", dto.Details["table"])
+	assert.Equal(t, []string{"org_id", "email"}, dto.Details["columns"])
+	assert.NotContains(t, fmt.Sprint(dto.Details), "john@doe.com")
+
+	err = TranslateError(errors.KindSystemError, &pgconn.PgError{Code: "23502", ColumnName: "name"}, "failed to insert resource")
+	assert.Equal(t, []string{"name"}, err.(*errors.ErrorT).Details["columns"])
+}
@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Field is a struct field mapped to a column through its db tag.
+type Field struct {
+	Column string
+	Index  []int
+	Tag    reflect.StructTag
+}
+
+// Columns lists the columns of a resource, read from the db tags of its
+// fields in declaration order. Embedded structs without a db tag are
+// flattened, like sqlx does when scanning rows.
+func Columns(resource any) []string {
+	fields := Fields(resource)
+
+	cols := make([]string, len(fields))
+	for i, field := range fields {
+		cols[i] = field.Column
+	}
+
+	return cols
+}
+
+// Fields lists the fields of a resource mapped to columns, in the same
+// order as Columns, keeping their tags so other options can be read.
+func Fields(resource any) []Field {
+	t := reflect.TypeOf(resource)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		panic("resource is not a struct")
+	}
+
+	return fields(t, nil)
+}
+
+func fields(t reflect.Type, index []int) []Field {
+	fs := []Field{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		dbTag, _, _ := strings.Cut(field.Tag.Get("db"), ",")
+		fieldIndex := append(append([]int{}, index...), i)
+
+		if field.Anonymous && dbTag == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				fs = append(fs, fields(ft, fieldIndex)...)
+			}
+			continue
+		}
+
+		if dbTag == "" || dbTag == "-" {
+			continue // Skip fields without db tags
+		}
+
+		fs = append(fs, Field{Column: dbTag, Index: fieldIndex, Tag: field.Tag})
+	}
+
+	return fs
+}
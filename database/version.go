@@ -0,0 +1,44 @@
+package database
+
+import (
+	"database/sql/driver"
+
+	"github.com/dexlabsio/garlic/errors"
+)
+
+// Version is the version of a resource as it was read by the client, bound
+// as the argument of an optimistic lock predicate:
+//
+//	UPDATE users SET name = $1, version = version + 1 WHERE id = $2 AND version = $3
+//
+// When an Update or a Read given a Version argument matches no row, the
+// resource was changed or deleted since it was read, and they fail with
+// KindDatabaseOptimisticLockError instead of KindNotFoundError.
+type Version int64
+
+// Value implements driver.Valuer.
+func (v Version) Value() (driver.Value, error) {
+	return int64(v), nil
+}
+
+// versioned reports whether the query is guarded by a Version argument.
+func versioned(args []any) (Version, bool) {
+	for _, arg := range args {
+		if v, ok := arg.(Version); ok {
+			return v, true
+		}
+	}
+
+	return 0, false
+}
+
+func optimisticLockError(version Version, opts ...errors.Opt) error {
+	return errors.New(
+		KindDatabaseOptimisticLockError,
+		"resource was modified concurrently",
+		append(opts,
+			errors.Detail("version", int64(version)),
+			errors.Hint("The resource was changed since it was read. Please fetch it again and retry with the new version."),
+		)...,
+	)
+}
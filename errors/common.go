@@ -96,6 +96,102 @@ var (
 		HTTPStatusCode: http.StatusInternalServerError,
 		Parent:         KindSystemError,
 	}
+
+	KindConflictError = &Kind{
+		Name:           "ConflictError",
+		Code:           "E00008",
+		Description:    "The request conflicts with the current state of the resource.",
+		HTTPStatusCode: http.StatusConflict,
+		Parent:         KindUserError,
+	}
+
+	KindDatabaseUniqueViolationError = &Kind{
+		Name:           "DatabaseUniqueViolationError",
+		Code:           "E00009",
+		Description:    "Another resource with the same unique fields already exists.",
+		HTTPStatusCode: http.StatusConflict,
+		Parent:         KindConflictError,
+	}
+
+	KindDatabaseForeignKeyViolationError = &Kind{
+		Name:           "DatabaseForeignKeyViolationError",
+		Code:           "E00010",
+		Description:    "The resource references a missing resource or is still referenced by another one.",
+		HTTPStatusCode: http.StatusConflict,
+		Parent:         KindConflictError,
+	}
+
+	KindDatabaseExclusionViolationError = &Kind{
+		Name:           "DatabaseExclusionViolationError",
+		Code:           "E00011",
+		Description:    "The resource conflicts with an existing resource.",
+		HTTPStatusCode: http.StatusConflict,
+		Parent:         KindConflictError,
+	}
+
+	KindDatabaseCheckViolationError = &Kind{
+		Name:           "DatabaseCheckViolationError",
+		Code:           "E00012",
+		Description:    "A field of the resource has a value that is not allowed.",
+		HTTPStatusCode: http.StatusBadRequest,
+		Parent:         KindInvalidRequestError,
+	}
+
+	KindDatabaseNotNullViolationError = &Kind{
+		Name:           "DatabaseNotNullViolationError",
+		Code:           "E00013",
+		Description:    "A required field of the resource is missing.",
+		HTTPStatusCode: http.StatusBadRequest,
+		Parent:         KindInvalidRequestError,
+	}
+
+	KindDatabaseOptimisticLockError = &Kind{
+		Name:           "DatabaseOptimisticLockError",
+		Code:           "E00014",
+		Description:    "The resource was changed by someone else since it was read.",
+		HTTPStatusCode: http.StatusConflict,
+		Parent:         KindConflictError,
+	}
+
+	KindDatabaseSerializationError = &Kind{
+		Name:           "DatabaseSerializationError",
+		Code:           "S00006",
+		Description:    "A transaction could not be serialized with concurrent transactions.",
+		HTTPStatusCode: http.StatusInternalServerError,
+		Parent:         KindDatabaseTransactionError,
+	}
+
+	KindDatabaseDeadlockError = &Kind{
+		Name:           "DatabaseDeadlockError",
+		Code:           "S00007",
+		Description:    "A transaction was aborted to break a deadlock.",
+		HTTPStatusCode: http.StatusInternalServerError,
+		Parent:         KindDatabaseTransactionError,
+	}
+
+	KindDatabaseLockTimeoutError = &Kind{
+		Name:           "DatabaseLockTimeoutError",
+		Code:           "S00008",
+		Description:    "A database lock could not be acquired in time.",
+		HTTPStatusCode: http.StatusInternalServerError,
+		Parent:         KindSystemError,
+	}
+
+	KindDatabaseQueryCanceledError = &Kind{
+		Name:           "DatabaseQueryCanceledError",
+		Code:           "S00009",
+		Description:    "A database query was cancelled or timed out.",
+		HTTPStatusCode: http.StatusInternalServerError,
+		Parent:         KindSystemError,
+	}
+
+	KindDatabaseConnectionError = &Kind{
+		Name:           "DatabaseConnectionError",
+		Code:           "S00010",
+		Description:    "The database is unreachable or closed the connection.",
+		HTTPStatusCode: http.StatusServiceUnavailable,
+		Parent:         KindSystemError,
+	}
 )
 
 func init() {
@@ -112,5 +208,17 @@ func init() {
 		KindContextValueNotFoundError,
 		KindDatabaseRecordNotFoundError,
 		KindDatabaseTransactionError,
+		KindConflictError,
+		KindDatabaseUniqueViolationError,
+		KindDatabaseForeignKeyViolationError,
+		KindDatabaseExclusionViolationError,
+		KindDatabaseCheckViolationError,
+		KindDatabaseNotNullViolationError,
+		KindDatabaseOptimisticLockError,
+		KindDatabaseSerializationError,
+		KindDatabaseDeadlockError,
+		KindDatabaseLockTimeoutError,
+		KindDatabaseQueryCanceledError,
+		KindDatabaseConnectionError,
 	)
 }
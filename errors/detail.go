@@ -0,0 +1,20 @@
+package errors
+
+type detail struct {
+	key   string
+	value any
+}
+
+// Detail adds a public detail to the error. Details are part of the error
+// DTO returned to users, so they must never carry sensitive information;
+// use Context for troubleshooting data instead.
+func Detail(key string, value any) *detail {
+	return &detail{
+		key:   key,
+		value: value,
+	}
+}
+
+func (d *detail) Opt(e *ErrorT) {
+	e.Details[d.key] = d.value
+}
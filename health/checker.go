@@ -0,0 +1,31 @@
+package health
+
+import "context"
+
+// Checker verifies the health of a single dependency. Check must return
+// a non-nil error when the dependency is not healthy.
+type Checker interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+type checkerFunc struct {
+	name string
+	fn   func(context.Context) error
+}
+
+// Func creates a Checker with the given name from a plain function.
+func Func(name string, fn func(context.Context) error) Checker {
+	return &checkerFunc{
+		name: name,
+		fn:   fn,
+	}
+}
+
+func (c *checkerFunc) Name() string {
+	return c.name
+}
+
+func (c *checkerFunc) Check(ctx context.Context) error {
+	return c.fn(ctx)
+}
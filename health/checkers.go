@@ -0,0 +1,58 @@
+package health
+
+import (
+	"context"
+
+	"github.com/dexlabsio/garlic/database"
+	"github.com/dexlabsio/garlic/errors"
+	"github.com/dexlabsio/garlic/httpclient"
+	"github.com/dexlabsio/garlic/worker"
+)
+
+// Database creates a Checker that pings the given database.
+func Database(name string, db *database.Database) Checker {
+	return Func(name, func(ctx context.Context) error {
+		if db.DB == nil {
+			return errors.New(errors.KindSystemError, "database is not connected")
+		}
+
+		if err := db.PingContext(ctx); err != nil {
+			return errors.PropagateAs(errors.KindSystemError, err, "failed to ping database")
+		}
+
+		return nil
+	})
+}
+
+// HTTP creates a Checker that sends a GET request to the given URI of an
+// outbound connector target and expects a 2xx response.
+func HTTP(name string, connector *httpclient.Connector, uri string) Checker {
+	return Func(name, func(ctx context.Context) error {
+		if err := connector.Ping(ctx, uri); err != nil {
+			return errors.Propagate(err, "failed to ping external service")
+		}
+
+		return nil
+	})
+}
+
+// WorkerPool creates a Checker that fails when the ratio of busy workers
+// in the pool reaches the given threshold, from 0 to 1.
+func WorkerPool(name string, pool *worker.Pool, threshold float64) Checker {
+	return Func(name, func(ctx context.Context) error {
+		saturation := pool.Saturation()
+		if saturation >= threshold {
+			return errors.New(
+				errors.KindSystemError,
+				"worker pool is saturated",
+				errors.Context(
+					errors.Field("pool_size", pool.Size()),
+					errors.Field("pool_busy", pool.Busy()),
+					errors.Field("threshold", threshold),
+				),
+			)
+		}
+
+		return nil
+	})
+}
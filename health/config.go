@@ -0,0 +1,18 @@
+package health
+
+import "time"
+
+type Config struct {
+	// Timeout bounds the execution of every single check.
+	Timeout time.Duration `mapstructure:"timeout" yaml:"timeout"`
+	// CacheTTL is how long a check result is reused before the check runs
+	// again, protecting dependencies from aggressive probing.
+	CacheTTL time.Duration `mapstructure:"cache_ttl" yaml:"cache_ttl"`
+}
+
+func Defaults() *Config {
+	return &Config{
+		Timeout:  2 * time.Second,
+		CacheTTL: 5 * time.Second,
+	}
+}
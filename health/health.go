@@ -0,0 +1,226 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dexlabsio/garlic/errors"
+	"github.com/dexlabsio/garlic/logging"
+	"go.uber.org/zap"
+)
+
+const (
+	LivePath  = "/health/live"
+	ReadyPath = "/health/ready"
+)
+
+type Status string
+
+const (
+	StatusUp   Status = "up"
+	StatusDown Status = "down"
+)
+
+// Result is the outcome of a single check.
+type Result struct {
+	Status    Status    `json:"status"`
+	LatencyMs float64   `json:"latency_ms"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+	Cached    bool      `json:"cached"`
+}
+
+// Report aggregates the results of every check of a probe. The report
+// is up only when all of its checks are up.
+type Report struct {
+	Status Status             `json:"status"`
+	Checks map[string]*Result `json:"checks"`
+}
+
+// check wraps a Checker with its cached result.
+type check struct {
+	checker Checker
+
+	mu      sync.Mutex
+	result  Result
+	expires time.Time
+}
+
+type Health struct {
+	config *Config
+	live   []*check
+	ready  []*check
+}
+
+// New creates the health checks of the service, configured with the
+// defaults when config is nil.
+func New(config *Config) *Health {
+	if config == nil {
+		config = Defaults()
+	}
+
+	return &Health{config: config}
+}
+
+// Liveness registers checkers that tell whether the process is alive.
+// A failing liveness probe usually makes the orchestrator restart the
+// process, so only register checks that a restart can fix.
+func (h *Health) Liveness(checkers ...Checker) {
+	for _, c := range checkers {
+		h.live = append(h.live, &check{checker: c})
+	}
+}
+
+// Readiness registers checkers that tell whether the process can serve
+// traffic, such as its database and the external services it depends on.
+func (h *Health) Readiness(checkers ...Checker) {
+	for _, c := range checkers {
+		h.ready = append(h.ready, &check{checker: c})
+	}
+}
+
+// Live runs the liveness checks.
+func (h *Health) Live(ctx context.Context) *Report {
+	return h.run(ctx, h.live, nil)
+}
+
+// Ready runs the readiness checks. Gates are checkers that run on every
+// call, bypassing the cache, and are meant for cheap in-process states
+// such as a server draining its connections.
+func (h *Health) Ready(ctx context.Context, gates ...Checker) *Report {
+	return h.run(ctx, h.ready, gates)
+}
+
+// LiveHandler serves the liveness report.
+func (h *Health) LiveHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeReport(w, h.Live(r.Context()))
+	}
+}
+
+// ReadyHandler serves the readiness report, evaluating the given gates
+// before the cached checks.
+func (h *Health) ReadyHandler(gates ...Checker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeReport(w, h.Ready(r.Context(), gates...))
+	}
+}
+
+// run executes the cached checks and the gates concurrently and
+// aggregates their results into a report.
+func (h *Health) run(ctx context.Context, checks []*check, gates []Checker) *Report {
+	report := &Report{
+		Status: StatusUp,
+		Checks: make(map[string]*Result, len(checks)+len(gates)),
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+
+	collect := func(name string, result Result) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		report.Checks[name] = &result
+		if result.Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+
+	for _, gate := range gates {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			collect(gate.Name(), h.execute(ctx, gate))
+		}()
+	}
+
+	for _, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			collect(c.checker.Name(), h.cached(ctx, c))
+		}()
+	}
+
+	wg.Wait()
+	return report
+}
+
+// cached returns the last result of the check while it's fresh, or runs
+// the check again. Callers arriving while the check runs run it too, and
+// the latest result is kept.
+func (h *Health) cached(ctx context.Context, c *check) Result {
+	c.mu.Lock()
+	if time.Now().Before(c.expires) {
+		result := c.result
+		result.Cached = true
+		c.mu.Unlock()
+		return result
+	}
+	c.mu.Unlock()
+
+	// Checks run without the lock, so a slow check doesn't hold back the
+	// probes that only need its cached result.
+	result := h.execute(ctx, c.checker)
+
+	// Failures caused by the caller giving up say nothing about the
+	// dependency, so they're not kept for other probes.
+	if ctx.Err() != nil {
+		return result
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if result.CheckedAt.After(c.result.CheckedAt) {
+		c.result = result
+		c.expires = result.CheckedAt.Add(h.config.CacheTTL)
+	}
+
+	return result
+}
+
+// execute runs a checker bounded by the configured timeout.
+func (h *Health) execute(ctx context.Context, checker Checker) Result {
+	ctx, cancel := context.WithTimeout(ctx, h.config.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := checker.Check(ctx)
+
+	result := Result{
+		Status:    StatusUp,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+		CheckedAt: start,
+	}
+
+	if err != nil {
+		logging.Global().Warn("Health check failed.", zap.String("check", checker.Name()), errors.Zap(err))
+		result.Status = StatusDown
+		result.Error = errors.NewDTO(err).Error
+	}
+
+	return result
+}
+
+// writeReport encodes the report answering 200 when it's up and 503
+// otherwise, so orchestrators can rely on the status code alone.
+func writeReport(w http.ResponseWriter, report *Report) {
+	statusCode := http.StatusOK
+	if report.Status != StatusUp {
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		logging.Global().Error("Failed to encode health report.", zap.Error(err))
+	}
+}
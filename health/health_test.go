@@ -0,0 +1,116 @@
+//go:build unit
+// +build unit
+
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadyHandler(t *testing.T) {
+	up := Func("up", func(context.Context) error { return nil })
+	down := Func("down", func(context.Context) error { return fmt.Errorf("unreachable") })
+
+	cases := []struct {
+		title          string
+		checkers       []Checker
+		gates          []Checker
+		expectedStatus int
+		expectedReport Status
+	}{
+		{
+			title:          "all checks up should answer 200",
+			checkers:       []Checker{up},
+			expectedStatus: http.StatusOK,
+			expectedReport: StatusUp,
+		},
+		{
+			title:          "any check down should answer 503",
+			checkers:       []Checker{up, down},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedReport: StatusDown,
+		},
+		{
+			title:          "a failing gate should answer 503",
+			checkers:       []Checker{up},
+			gates:          []Checker{down},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedReport: StatusDown,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			h := New(Defaults())
+			h.Readiness(tc.checkers...)
+
+			rec := httptest.NewRecorder()
+			h.ReadyHandler(tc.gates...).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, ReadyPath, nil))
+
+			var report Report
+			if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.Equal(t, tc.expectedReport, report.Status)
+			assert.Len(t, report.Checks, len(tc.checkers)+len(tc.gates))
+		})
+	}
+}
+
+func TestCachedResults(t *testing.T) {
+	calls := 0
+	counter := Func("counter", func(context.Context) error {
+		calls++
+		return nil
+	})
+
+	h := New(&Config{Timeout: time.Second, CacheTTL: time.Minute})
+	h.Readiness(counter)
+
+	first := h.Ready(context.Background())
+	second := h.Ready(context.Background())
+
+	assert.Equal(t, 1, calls)
+	assert.False(t, first.Checks["counter"].Cached)
+	assert.True(t, second.Checks["counter"].Cached)
+}
+
+func TestCancelledChecksAreNotCached(t *testing.T) {
+	calls := 0
+	counter := Func("counter", func(ctx context.Context) error {
+		calls++
+		return ctx.Err()
+	})
+
+	h := New(&Config{Timeout: time.Second, CacheTTL: time.Minute})
+	h.Readiness(counter)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.Equal(t, StatusDown, h.Ready(ctx).Status)
+
+	report := h.Ready(context.Background())
+	assert.Equal(t, StatusUp, report.Status)
+	assert.False(t, report.Checks["counter"].Cached)
+	assert.Equal(t, 2, calls)
+}
+
+func TestNewWithoutConfig(t *testing.T) {
+	h := New(nil)
+	h.Liveness(Func("up", func(context.Context) error { return nil }))
+
+	assert.NotPanics(t, func() {
+		assert.Equal(t, StatusUp, h.Live(context.Background()).Status)
+	})
+}
@@ -16,30 +16,30 @@ import (
 
 // Post sends a HTTP POST request to the given url
 func Post(ctx context.Context, url string, data any) (*http.Response, error) {
-	return request(ctx, http.MethodPost, url, data)
+	return request(ctx, &http.Client{}, http.MethodPost, url, data)
 }
 
 // Put sends a HTTP PUT request to the given url
 func Put(ctx context.Context, url string, data any) (*http.Response, error) {
-	return request(ctx, http.MethodPut, url, data)
+	return request(ctx, &http.Client{}, http.MethodPut, url, data)
 }
 
 // Patch sends a HTTP PATCH request to the given url
 func Patch(ctx context.Context, url string, data any) (*http.Response, error) {
-	return request(ctx, http.MethodPatch, url, data)
+	return request(ctx, &http.Client{}, http.MethodPatch, url, data)
 }
 
 // Get sends a HTTP GET request to the given url
 func Get(ctx context.Context, url string) (*http.Response, error) {
-	return request(ctx, http.MethodGet, url, nil)
+	return request(ctx, &http.Client{}, http.MethodGet, url, nil)
 }
 
 // Delete sends a HTTP DELETE request to the given url
 func Delete(ctx context.Context, url string) (*http.Response, error) {
-	return request(ctx, http.MethodDelete, url, nil)
+	return request(ctx, &http.Client{}, http.MethodDelete, url, nil)
 }
 
-func request(ctx context.Context, method, url string, data any) (*http.Response, error) {
+func request(ctx context.Context, client *http.Client, method, url string, data any) (*http.Response, error) {
 	ectx := errors.Context(
 		errors.Field("http_method", method),
 		errors.Field("http_url", url),
@@ -69,8 +69,7 @@ func request(ctx context.Context, method, url string, data any) (*http.Response,
 		req.Header.Set("X-Request-ID", requestId.String())
 	}
 
-	// create net_http client and send request
-	client := &http.Client{}
+	// send request with the given net_http client
 	var res *http.Response
 
 	expBackoff := backoff.NewExponentialBackOff()
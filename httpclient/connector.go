@@ -19,10 +19,18 @@ type Request struct {
 
 type Connector struct {
 	config *Config
+	client *http.Client
 }
 
 func NewConnector(config *Config) *Connector {
-	return &Connector{config}
+	return &Connector{config: config, client: &http.Client{}}
+}
+
+// WithClient sets the HTTP client used by the connector, to configure its
+// timeouts, transport or TLS settings.
+func (c *Connector) WithClient(client *http.Client) *Connector {
+	c.client = client
+	return c
 }
 
 func (c *Connector) Request(ctx context.Context, req *Request, result any) error {
@@ -38,7 +46,7 @@ func (c *Connector) Request(ctx context.Context, req *Request, result any) error
 		return errors.PropagateAs(errors.KindSystemError, err, "failed to build request URL", ectx)
 	}
 
-	res, err := request(ctx, req.Method, target, req.Data)
+	res, err := request(ctx, c.client, req.Method, target, req.Data)
 	if err != nil {
 		return errors.Propagate(err, "failed to make request", ectx)
 	}
@@ -60,6 +68,46 @@ func (c *Connector) Request(ctx context.Context, req *Request, result any) error
 	return nil
 }
 
+// Ping sends a single GET request to the given URI of the connector's target
+// and reports an error if the target can't be reached or doesn't answer with
+// a 2xx status code. Unlike Request, it never retries, which makes it suitable
+// for health checks that must fail fast.
+func (c *Connector) Ping(ctx context.Context, uri string) error {
+	ectx := errors.Context(
+		errors.Field("http_url", c.config.URL),
+		errors.Field("http_uri", uri),
+	)
+
+	target, err := buildURL(c.config.URL, uri, nil)
+	if err != nil {
+		return errors.PropagateAs(errors.KindSystemError, err, "failed to build ping URL", ectx)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return errors.PropagateAs(errors.KindSystemError, err, "failed to create ping request", ectx)
+	}
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return errors.PropagateAs(errors.KindSystemError, err, "failed to reach external service", ectx)
+	}
+
+	defer func() {
+		_ = res.Body.Close()
+	}()
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return errors.New(
+			errors.KindSystemError,
+			"external service answered ping with an unexpected status",
+			ectx.Add(errors.Field("http_status_code", res.StatusCode)),
+		)
+	}
+
+	return nil
+}
+
 // buildURL parses the base, joins the URI path, sets params, and returns the final URL string.
 func buildURL(baseURL, uri string, params map[string]string) (string, error) {
 	u, err := url.Parse(baseURL)
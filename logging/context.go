@@ -22,6 +22,17 @@ func GetLoggerFromContext(ctx context.Context) *zap.Logger {
 	return logger
 }
 
+// GetLoggerFromContextOrGlobal is a helper function that retrieves the logger from
+// a context, falling back to the global logger when the context has none. It's
+// meant for code paths that can't assume the logging middleware ran before them.
+func GetLoggerFromContextOrGlobal(ctx context.Context) *zap.Logger {
+	if logger, ok := ctx.Value(LoggerKey).(*zap.Logger); ok {
+		return logger
+	}
+
+	return Global()
+}
+
 // SetContextLogger is a helper function that associates a logger with a context
 // by storing the logger in the context using a predefined key. This allows
 // the logger to be retrieved later from the context, enabling consistent
@@ -3,6 +3,7 @@ package middleware
 import (
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/dexlabsio/garlic/logging"
@@ -27,12 +28,12 @@ func Logging(next http.Handler) http.Handler {
 
 		r = request.SetLogger(r, l)
 
-		lrw := &loggingResponseWriter{w, http.StatusOK, 0}
+		lrw := &loggingResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
 		l.Debug(fmt.Sprintf("Handling %s %s", r.Method, r.URL.String()))
 		next.ServeHTTP(lrw, r)
 
-		// If the request is a health check, we don't need to log it.
-		if r.URL.String() == "/health" {
+		// If the request is a health check or a metrics scrape, we don't need to log it.
+		if isOperationalRoute(r.URL.Path) {
 			return
 		}
 
@@ -53,27 +54,38 @@ func Logging(next http.Handler) http.Handler {
 	})
 }
 
+// isOperationalRoute reports whether the path belongs to the health probes
+// or the metrics endpoint, which are too frequent to be worth logging or monitoring.
+func isOperationalRoute(path string) bool {
+	return path == "/health" || strings.HasPrefix(path, "/health/") || path == "/metrics"
+}
+
 // loggingResponseWriter is a custom HTTP response writer that captures
 // the status code and the size of the response. It embeds the standard
 // http.ResponseWriter and overrides the WriteHeader and Write methods to
 // store the status code and accumulate the size of the response body.
 // This allows for enhanced logging of HTTP responses, including the
-// status code and the total size of the response sent to the client.
+// status code and the total size of the response sent to the client. It
+// also tells whether the headers were sent, after which the response can't
+// be replaced.
 type loggingResponseWriter struct {
 	http.ResponseWriter
 	statusCode   int
 	responseSize int
+	wroteHeader  bool
 }
 
 // WriteHeader writes the status code to the response writer and stores it in.
 func (w *loggingResponseWriter) WriteHeader(statusCode int) {
 	w.ResponseWriter.WriteHeader(statusCode)
 	w.statusCode = statusCode
+	w.wroteHeader = true
 }
 
 // Write writes the data to the response writer and stores the size of the data.
 func (w *loggingResponseWriter) Write(data []byte) (int, error) {
 	size, err := w.ResponseWriter.Write(data)
 	w.responseSize += size
+	w.wroteHeader = true
 	return size, err
 }
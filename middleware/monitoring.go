@@ -74,7 +74,7 @@ func getRoutePattern(r *http.Request) string {
 
 // isIgnoredRoute checks if the route should be ignored from monitoring.
 func isIgnoredRoute(route string) bool {
-	return route == "/health"
+	return isOperationalRoute(route)
 }
 
 // monitorLatency measures and records the request latency.
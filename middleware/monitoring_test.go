@@ -6,10 +6,10 @@ package middleware
 import (
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"testing"
 	"time"
 
-	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	io_prometheus_client "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
@@ -17,10 +17,18 @@ import (
 	"github.com/dexlabsio/garlic/monitoring"
 )
 
+// TestMain registers the metrics in an isolated registry, so tests don't
+// depend on the state of the prometheus default one.
+func TestMain(m *testing.M) {
+	if err := monitoring.Init(monitoring.NewRegistry(monitoring.Defaults())); err != nil {
+		panic(err)
+	}
+
+	os.Exit(m.Run())
+}
+
 func TestTrafficMonitoring(t *testing.T) {
-	monitoring.TrafficMetric.Reset()
-	prometheus.Unregister(monitoring.TrafficMetric)
-	prometheus.MustRegister(monitoring.TrafficMetric)
+	monitoring.TrafficMetric().Reset()
 
 	// Create a test HTTP request and response recorder
 	req := httptest.NewRequest(http.MethodGet, "/test", nil)
@@ -42,7 +50,7 @@ func TestTrafficMonitoring(t *testing.T) {
 		t.Errorf("Expected status code %d, got %d", http.StatusOK, rec.Code)
 	}
 
-	collector, err := monitoring.TrafficMetric.GetMetricWithLabelValues("GET", "unknown", "200")
+	collector, err := monitoring.TrafficMetric().GetMetricWithLabelValues("GET", "unknown", "200")
 	if err != nil {
 		t.Error(err)
 	}
@@ -51,9 +59,7 @@ func TestTrafficMonitoring(t *testing.T) {
 }
 
 func TestActiveRequestsMonitoring(t *testing.T) {
-	monitoring.ActiveRequests.Reset()
-	prometheus.Unregister(monitoring.ActiveRequests)
-	prometheus.MustRegister(monitoring.ActiveRequests)
+	monitoring.ActiveRequests().Reset()
 
 	// Create a test HTTP request and response recorder
 	req := httptest.NewRequest(http.MethodGet, "/test", nil)
@@ -61,7 +67,7 @@ func TestActiveRequestsMonitoring(t *testing.T) {
 
 	// Create a test HTTP handler that will be wrapped by the middleware
 	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		activeRequestsGauge, err := monitoring.ActiveRequests.GetMetricWithLabelValues("GET", "unknown")
+		activeRequestsGauge, err := monitoring.ActiveRequests().GetMetricWithLabelValues("GET", "unknown")
 		if err != nil {
 			t.Error(err)
 		}
@@ -82,7 +88,7 @@ func TestActiveRequestsMonitoring(t *testing.T) {
 		t.Errorf("Expected status code %d, got %d", http.StatusOK, rec.Code)
 	}
 
-	labeledCollector, err := monitoring.ActiveRequests.GetMetricWithLabelValues("GET", "unknown")
+	labeledCollector, err := monitoring.ActiveRequests().GetMetricWithLabelValues("GET", "unknown")
 	if err != nil {
 		t.Error(err)
 	}
@@ -91,9 +97,7 @@ func TestActiveRequestsMonitoring(t *testing.T) {
 }
 
 func TestLatencyMonitoring(t *testing.T) {
-	monitoring.LatencyMetric.Reset()
-	prometheus.Unregister(monitoring.LatencyMetric)
-	prometheus.MustRegister(monitoring.LatencyMetric)
+	monitoring.LatencyMetric().Reset()
 
 	// Create a test HTTP request and response recorder
 	req := httptest.NewRequest(http.MethodGet, "/test", nil)
@@ -141,9 +145,9 @@ func TestLatencyMonitoring(t *testing.T) {
 	assert.Equal(t, expectedHistDiff, histDiff)
 }
 
-// extractHistogramFromGatherer extracts the histrogram of a target metric in the default gatherer into a map
+// extractHistogramFromGatherer extracts the histrogram of a target metric in the monitoring gatherer into a map
 func extractHistogramFromGatherer(t *testing.T, target string) map[float64]uint64 {
-	metricFamilies, err := prometheus.DefaultGatherer.Gather()
+	metricFamilies, err := monitoring.Global().Gatherer().Gather()
 	if err != nil {
 		t.Error(err)
 	}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/dexlabsio/garlic/errors"
+	"github.com/dexlabsio/garlic/logging"
+	"github.com/dexlabsio/garlic/monitoring"
+	"github.com/dexlabsio/garlic/rest"
+	"github.com/dexlabsio/garlic/tracing"
+)
+
+// Recover is a middleware that recovers from panics in the next handlers.
+// The panic is converted into a system error carrying the stack trace and
+// the request context, logged through the request logger, counted in the
+// panics metric and answered with the standard internal server error
+// response, unless the handler already started writing its own. Panics
+// with http.ErrAbortHandler are propagated, since they're the standard way
+// of aborting a response.
+func Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lrw := &loggingResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+
+			if p == http.ErrAbortHandler {
+				panic(p)
+			}
+
+			err := panicError(r, p)
+
+			l := logging.GetLoggerFromContextOrGlobal(r.Context())
+			l.Error("[PANIC]", errors.Zap(err))
+
+			monitoring.IncrementPanics(r.Method, getRoutePattern(r))
+
+			// A response already sent can't be replaced by the error.
+			if !lrw.wroteHeader {
+				rest.WriteError(err).Must(w)
+			}
+		}()
+
+		next.ServeHTTP(lrw, r)
+	})
+}
+
+// panicError converts a recovered value into a system error enriched with
+// the stack trace and the request tracing fields.
+func panicError(r *http.Request, p any) *errors.ErrorT {
+	ctx := r.Context()
+	ectx := errors.Context(
+		errors.Field("panic", fmt.Sprint(p)),
+		errors.Field("request_method", r.Method),
+		errors.Field("request_url", r.URL.String()),
+	)
+
+	if requestId, err := tracing.GetRequestIdFromContext(ctx); err == nil {
+		ectx.Add(errors.Field("request_id", requestId))
+	}
+
+	if sessionId, err := tracing.GetSessionIdFromContext(ctx); err == nil {
+		ectx.Add(errors.Field("session_id", sessionId))
+	}
+
+	if cause, ok := p.(error); ok {
+		return errors.PropagateAs(errors.KindSystemError, cause, "panic recovered while handling request", errors.StackTrace(), ectx)
+	}
+
+	return errors.New(errors.KindSystemError, "panic recovered while handling request", errors.StackTrace(), ectx)
+}
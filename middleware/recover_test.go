@@ -0,0 +1,73 @@
+//go:build unit
+// +build unit
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dexlabsio/garlic/monitoring"
+)
+
+func TestRecover(t *testing.T) {
+	monitoring.PanicMetric().Reset()
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	rec := httptest.NewRecorder()
+
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	handler := Recover(testHandler)
+	assert.NotPanics(t, func() { handler.ServeHTTP(rec, req) })
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.JSONEq(t, `{
+		"name": "SystemError::Error",
+		"error": "internal server error",
+		"kind": "S00001",
+		"details": {"hint": "internal server error, please contact the support"}
+	}`, rec.Body.String())
+
+	collector, err := monitoring.PanicMetric().GetMetricWithLabelValues("GET", "unknown")
+	if err != nil {
+		t.Error(err)
+	}
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(collector))
+}
+
+func TestRecoverAfterWriting(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	rec := httptest.NewRecorder()
+
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte("partial"))
+		panic("boom")
+	})
+
+	handler := Recover(testHandler)
+	assert.NotPanics(t, func() { handler.ServeHTTP(rec, req) })
+
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Equal(t, "partial", rec.Body.String())
+}
+
+func TestRecoverAbortHandler(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	rec := httptest.NewRecorder()
+
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})
+
+	handler := Recover(testHandler)
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() { handler.ServeHTTP(rec, req) })
+}
@@ -0,0 +1,26 @@
+package monitoring
+
+import "github.com/dexlabsio/garlic/global"
+
+type Config struct {
+	Namespace   string            `mapstructure:"namespace" yaml:"namespace"`
+	Subsystem   string            `mapstructure:"subsystem" yaml:"subsystem"`
+	ConstLabels map[string]string `mapstructure:"const_labels" yaml:"const_labels"`
+}
+
+func Defaults() *Config {
+	return &Config{
+		Namespace:   "",
+		Subsystem:   "",
+		ConstLabels: map[string]string{},
+	}
+}
+
+// ServiceLabels returns the const labels that identify a service and its
+// running version, meant to be used as Config.ConstLabels.
+func ServiceLabels(service string) map[string]string {
+	return map[string]string{
+		"service": service,
+		"version": global.Version,
+	}
+}
@@ -2,59 +2,184 @@ package monitoring
 
 import (
 	"strconv"
+	"sync"
+	"sync/atomic"
 
 	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/dexlabsio/garlic/errors"
 )
 
 var (
-	TrafficMetric = prometheus.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "http_request_total",
-			Help: "Total number of HTTP requests.",
-		},
+	mu      sync.Mutex
+	current atomic.Pointer[Registry]
+
+	// The garlic metrics, created by Init in the registry it's given. They
+	// used to be exported variables set when the package was imported; they
+	// are now read through accessors, like TrafficMetric, which initialize
+	// them first when Init was never called.
+	trafficMetric  *prometheus.CounterVec
+	activeRequests *prometheus.GaugeVec
+	latencyMetric  *prometheus.HistogramVec
+	panicMetric    *prometheus.CounterVec
+	txRetryMetric  *prometheus.CounterVec
+	queryMetric    *prometheus.HistogramVec
+)
+
+// Init creates the garlic metrics in the given registry and makes it the
+// global one. It should be called once during the service startup, before
+// serving; otherwise the metrics are registered in the prometheus default
+// registry as soon as the first one is observed. Metrics are only created
+// once, so calling Init again with the same registry does nothing, and
+// with another one fails.
+func Init(registry *Registry) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if global := current.Load(); global != nil {
+		if global != registry {
+			return errors.New(
+				errors.KindSystemError,
+				"metrics are already registered in another registry",
+				errors.Hint("Please call monitoring.Init once, before serving or observing any metric."),
+			)
+		}
+
+		return nil
+	}
+
+	traffic := prometheus.NewCounterVec(
+		registry.CounterOpts("http_request_total", "Total number of HTTP requests."),
 		[]string{"method", "route", "status_code"},
 	)
 
-	ActiveRequests = prometheus.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Name: "http_active_requests",
-			Help: "Number of active HTTP requests.",
-		},
+	activeRequestsVec := prometheus.NewGaugeVec(
+		registry.GaugeOpts("http_active_requests", "Number of active HTTP requests."),
 		[]string{"method", "route"},
 	)
 
-	LatencyMetric = prometheus.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name: "http_request_duration_seconds",
-			Help: "Latency of HTTP requests.",
-		},
+	latency := prometheus.NewHistogramVec(
+		registry.HistogramOpts("http_request_duration_seconds", "Latency of HTTP requests."),
 		[]string{"method", "route"},
 	)
-)
+
+	panics := prometheus.NewCounterVec(
+		registry.CounterOpts("http_panics_total", "Total number of panics recovered while handling HTTP requests."),
+		[]string{"method", "route"},
+	)
+
+	txRetries := prometheus.NewCounterVec(
+		registry.CounterOpts("db_transaction_retries_total", "Total number of database transactions retried after serialization failures or deadlocks."),
+		[]string{"code"},
+	)
+
+	queries := prometheus.NewHistogramVec(
+		registry.HistogramOpts("db_query_duration_seconds", "Latency of database operations."),
+		[]string{"operation", "name", "status"},
+	)
+
+	if err := registry.Register(latency, traffic, activeRequestsVec, panics, txRetries, queries); err != nil {
+		return errors.Propagate(err, "failed to register metrics")
+	}
+
+	trafficMetric = traffic
+	activeRequests = activeRequestsVec
+	latencyMetric = latency
+	panicMetric = panics
+	txRetryMetric = txRetries
+	queryMetric = queries
+
+	// Published last, so metrics are set for whoever sees the registry.
+	current.Store(registry)
+	return nil
+}
+
+// Global returns the registry where garlic metrics are registered,
+// initializing them in the prometheus default registry when Init was never
+// called.
+func Global() *Registry {
+	if registry := current.Load(); registry != nil {
+		return registry
+	}
+
+	// Init may fail because another registry won a race, which is fine,
+	// or because the default registry has conflicting metrics, which is a
+	// programming error like when they were registered at startup.
+	if err := Init(DefaultRegistry(Defaults())); err != nil && current.Load() == nil {
+		panic(err)
+	}
+
+	return current.Load()
+}
+
+// TrafficMetric returns the counter of HTTP requests.
+func TrafficMetric() *prometheus.CounterVec {
+	Global()
+	return trafficMetric
+}
+
+// ActiveRequests returns the gauge of the HTTP requests being served.
+func ActiveRequests() *prometheus.GaugeVec {
+	Global()
+	return activeRequests
+}
+
+// LatencyMetric returns the histogram of the HTTP requests latency.
+func LatencyMetric() *prometheus.HistogramVec {
+	Global()
+	return latencyMetric
+}
+
+// PanicMetric returns the counter of the panics recovered while handling
+// HTTP requests.
+func PanicMetric() *prometheus.CounterVec {
+	Global()
+	return panicMetric
+}
+
+// TxRetryMetric returns the counter of the retried database transactions.
+func TxRetryMetric() *prometheus.CounterVec {
+	Global()
+	return txRetryMetric
+}
+
+// QueryMetric returns the histogram of the database operations latency.
+func QueryMetric() *prometheus.HistogramVec {
+	Global()
+	return queryMetric
+}
 
 // IncrementTraffic increments the traffic metric
 func IncrementTraffic(method, route string, status int) {
-	TrafficMetric.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	TrafficMetric().WithLabelValues(method, route, strconv.Itoa(status)).Inc()
 }
 
 // IncrementActiveRequests increments the active requests metric
 func IncrementActiveRequests(method, route string) {
-	ActiveRequests.WithLabelValues(method, route).Inc()
+	ActiveRequests().WithLabelValues(method, route).Inc()
 }
 
 // DecrementActiveRequests decrements the active requests metric
 func DecrementActiveRequests(method, route string) {
-	ActiveRequests.WithLabelValues(method, route).Dec()
+	ActiveRequests().WithLabelValues(method, route).Dec()
 }
 
 // ObserveLatency observes the latency metric
 func ObserveLatency(method, route string, latency float64) {
-	LatencyMetric.WithLabelValues(method, route).Observe(latency)
+	LatencyMetric().WithLabelValues(method, route).Observe(latency)
+}
+
+// IncrementPanics increments the recovered panics metric
+func IncrementPanics(method, route string) {
+	PanicMetric().WithLabelValues(method, route).Inc()
+}
+
+// IncrementTransactionRetries increments the database transaction retries metric
+func IncrementTransactionRetries(code string) {
+	TxRetryMetric().WithLabelValues(code).Inc()
 }
 
-// init registers all metrics in the default registerer
-func init() {
-	prometheus.MustRegister(LatencyMetric)
-	prometheus.MustRegister(TrafficMetric)
-	prometheus.MustRegister(ActiveRequests)
+// ObserveQuery observes the database query latency metric
+func ObserveQuery(operation, name, status string, latency float64) {
+	QueryMetric().WithLabelValues(operation, name, status).Observe(latency)
 }
@@ -0,0 +1,62 @@
+//go:build unit
+// +build unit
+
+package monitoring
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInit(t *testing.T) {
+	current.Store(nil)
+	defer current.Store(nil)
+
+	config := Defaults()
+	config.Namespace = "garlic"
+	registry := NewRegistry(config)
+
+	assert.NoError(t, Init(registry))
+	assert.NoError(t, Init(registry))
+	assert.Error(t, Init(NewRegistry(Defaults())))
+	assert.Same(t, registry, Global())
+
+	IncrementTraffic("GET", "/", 200)
+
+	count, err := testutil.GatherAndCount(registry.Gatherer(), "garlic_http_request_total")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	count, err = testutil.GatherAndCount(prometheus.DefaultGatherer, "http_request_total", "garlic_http_request_total")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
+
+func TestInitRollsBackFailedRegistrations(t *testing.T) {
+	current.Store(nil)
+	defer current.Store(nil)
+
+	registry := NewRegistry(Defaults())
+
+	// The panics metric is registered after the other HTTP metrics, which
+	// must be unregistered when the conflict fails Init.
+	conflict := prometheus.NewCounterVec(
+		registry.CounterOpts("http_panics_total", "Total number of panics recovered while handling HTTP requests."),
+		[]string{"method", "route"},
+	)
+	registry.MustRegister(conflict)
+
+	assert.Error(t, Init(registry))
+	assert.Nil(t, current.Load())
+
+	registry.Registerer().Unregister(conflict)
+	assert.NoError(t, Init(registry))
+
+	PanicMetric().WithLabelValues("GET", "/").Inc()
+	count, err := testutil.GatherAndCount(registry.Gatherer(), "http_panics_total")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
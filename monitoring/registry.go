@@ -0,0 +1,115 @@
+package monitoring
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry is where metrics are registered and gathered from. It also
+// carries the naming configuration applied to every metric it creates.
+type Registry struct {
+	config     *Config
+	registerer prometheus.Registerer
+	gatherer   prometheus.Gatherer
+}
+
+// NewRegistry creates an isolated registry, including the standard Go
+// runtime and process collectors.
+func NewRegistry(config *Config) *Registry {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	return FromPrometheus(config, reg, reg)
+}
+
+// DefaultRegistry wraps the prometheus global default registerer and gatherer.
+func DefaultRegistry(config *Config) *Registry {
+	return FromPrometheus(config, prometheus.DefaultRegisterer, prometheus.DefaultGatherer)
+}
+
+// FromPrometheus wraps an existing registerer and gatherer, allowing services
+// to share the registry they already use for their own metrics.
+func FromPrometheus(config *Config, registerer prometheus.Registerer, gatherer prometheus.Gatherer) *Registry {
+	return &Registry{
+		config:     config,
+		registerer: registerer,
+		gatherer:   gatherer,
+	}
+}
+
+// Registerer returns the underlying prometheus registerer.
+func (r *Registry) Registerer() prometheus.Registerer {
+	return r.registerer
+}
+
+// Gatherer returns the underlying prometheus gatherer.
+func (r *Registry) Gatherer() prometheus.Gatherer {
+	return r.gatherer
+}
+
+// MustRegister registers the collectors, panicking on failure.
+func (r *Registry) MustRegister(cs ...prometheus.Collector) {
+	r.registerer.MustRegister(cs...)
+}
+
+// Register registers the collectors, all or none: when one can't be
+// registered, the ones registered before it are unregistered, so the
+// registry is left as it was.
+func (r *Registry) Register(cs ...prometheus.Collector) error {
+	for i, c := range cs {
+		if err := r.registerer.Register(c); err != nil {
+			for _, registered := range cs[:i] {
+				r.registerer.Unregister(registered)
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Handler serves the gathered metrics in the prometheus exposition format.
+func (r *Registry) Handler() http.Handler {
+	return promhttp.HandlerFor(r.gatherer, promhttp.HandlerOpts{
+		ErrorHandling: promhttp.ContinueOnError,
+	})
+}
+
+// CounterOpts returns counter options carrying the registry naming configuration.
+func (r *Registry) CounterOpts(name, help string) prometheus.CounterOpts {
+	return prometheus.CounterOpts{
+		Namespace:   r.config.Namespace,
+		Subsystem:   r.config.Subsystem,
+		Name:        name,
+		Help:        help,
+		ConstLabels: r.config.ConstLabels,
+	}
+}
+
+// GaugeOpts returns gauge options carrying the registry naming configuration.
+func (r *Registry) GaugeOpts(name, help string) prometheus.GaugeOpts {
+	return prometheus.GaugeOpts{
+		Namespace:   r.config.Namespace,
+		Subsystem:   r.config.Subsystem,
+		Name:        name,
+		Help:        help,
+		ConstLabels: r.config.ConstLabels,
+	}
+}
+
+// HistogramOpts returns histogram options carrying the registry naming configuration.
+func (r *Registry) HistogramOpts(name, help string) prometheus.HistogramOpts {
+	return prometheus.HistogramOpts{
+		Namespace:   r.config.Namespace,
+		Subsystem:   r.config.Subsystem,
+		Name:        name,
+		Help:        help,
+		ConstLabels: r.config.ConstLabels,
+	}
+}
@@ -0,0 +1,323 @@
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dexlabsio/garlic/request"
+	"github.com/google/uuid"
+)
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	uuidType            = reflect.TypeOf(uuid.UUID{})
+	rawMessageType      = reflect.TypeOf(json.RawMessage{})
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	packageQualifierReg = regexp.MustCompile(`[\w./-]*/|\w+\.`)
+	invalidNameCharsReg = regexp.MustCompile(`\W+`)
+)
+
+// Reflector builds schemas out of Go types. Named structs are added to the
+// components of the document and referenced by name, while anonymous ones
+// are inlined. Fields are described by the same json and validate tags
+// consumed by encoding/json and the validator package.
+type Reflector struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func NewReflector(doc *Document) *Reflector {
+	return &Reflector{
+		schemas: doc.Components.Schemas,
+		names:   map[reflect.Type]string{},
+	}
+}
+
+// Schema returns the schema of the given type.
+func (r *Reflector) Schema(t reflect.Type) *Schema {
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == uuidType:
+		return &Schema{Type: "string", Format: "uuid"}
+	case t == rawMessageType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		s := r.Schema(t.Elem())
+		if s.Ref != "" {
+			return s
+		}
+		s.Nullable = true
+		return s
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	}
+
+	if t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType) {
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: r.Schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: r.Schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			// Anonymous structs have no name to be referenced by.
+			schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+			r.fields(t, schema)
+			return schema
+		}
+		return r.component(t)
+	}
+
+	// Interfaces and other dynamic values accept anything.
+	return &Schema{}
+}
+
+// Parameters returns the path and query parameters declared by the
+// fields of a struct type through the request.PathTag and request.QueryTag tags.
+func (r *Reflector) Parameters(t reflect.Type) []*Parameter {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	params := []*Parameter{}
+	if t.Kind() != reflect.Struct {
+		return params
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			params = append(params, r.Parameters(field.Type)...)
+			continue
+		}
+
+		for _, in := range []string{request.PathTag, request.QueryTag} {
+			name := field.Tag.Get(in)
+			if name == "" {
+				continue
+			}
+
+			schema := r.Schema(field.Type)
+			required := applyValidation(schema, field.Tag.Get("validate"))
+			params = append(params, &Parameter{
+				Name:     name,
+				In:       in,
+				Required: required || in == request.PathTag,
+				Schema:   schema,
+			})
+		}
+	}
+
+	return params
+}
+
+// HasBody reports whether a struct type has any field decoded from the
+// request body.
+func (r *Reflector) HasBody(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return true
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() || isParamOnly(field) {
+			continue
+		}
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if r.HasBody(field.Type) {
+				return true
+			}
+			continue
+		}
+
+		if field.Tag.Get("json") != "-" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// component registers the struct in the document components and returns
+// a reference to it.
+func (r *Reflector) component(t reflect.Type) *Schema {
+	if name, ok := r.names[t]; ok {
+		return Ref(name)
+	}
+
+	name := componentName(t)
+	if _, taken := r.schemas[name]; taken {
+		// Another type with the same name lives in a different package.
+		name = componentName(t) + "_" + strconv.Itoa(len(r.names))
+	}
+
+	// Register the name before reflecting the fields to support recursive types.
+	r.names[t] = name
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	r.schemas[name] = schema
+
+	r.fields(t, schema)
+	return Ref(name)
+}
+
+// fields reflects the fields of a struct into the given object schema,
+// flattening embedded structs like encoding/json does.
+func (r *Reflector) fields(t reflect.Type, schema *Schema) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() || isParamOnly(field) {
+			continue
+		}
+
+		jsonTag := field.Tag.Get("json")
+		if jsonTag == "-" {
+			continue
+		}
+
+		name, _, _ := strings.Cut(jsonTag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			r.fields(field.Type, schema)
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		property := r.Schema(field.Type)
+		if applyValidation(property, field.Tag.Get("validate")) {
+			schema.Required = append(schema.Required, name)
+		}
+
+		schema.Properties[name] = property
+	}
+}
+
+// isParamOnly reports whether the field is only filled from the URL,
+// which means it's not part of the request body.
+func isParamOnly(field reflect.StructField) bool {
+	_, hasJSON := field.Tag.Lookup("json")
+	isParam := field.Tag.Get(request.PathTag) != "" || field.Tag.Get(request.QueryTag) != ""
+	return isParam && !hasJSON
+}
+
+// applyValidation translates the validator rules into schema constraints
+// and reports whether the field is required. Rules after `dive` apply to
+// the elements of a collection and are ignored.
+func applyValidation(schema *Schema, tag string) (required bool) {
+	if tag == "" {
+		return false
+	}
+
+	for _, rule := range strings.Split(tag, ",") {
+		key, param, _ := strings.Cut(rule, "=")
+		switch key {
+		case "dive":
+			return required
+		case "required":
+			required = true
+		case "email":
+			schema.Format = "email"
+		case "url", "uri", "http_url":
+			schema.Format = "uri"
+		case "uuid", "uuid4", "uuid_rfc4122", "uuid4_rfc4122":
+			schema.Format = "uuid"
+		case "datetime":
+			if param == "2006-01-02" {
+				schema.Format = "date"
+			}
+		case "oneof":
+			for _, v := range strings.Fields(param) {
+				schema.Enum = append(schema.Enum, v)
+			}
+		case "len":
+			applyBound(schema, param, true, false)
+			applyBound(schema, param, false, false)
+		case "min", "gte":
+			applyBound(schema, param, true, false)
+		case "max", "lte":
+			applyBound(schema, param, false, false)
+		case "gt":
+			applyBound(schema, param, true, true)
+		case "lt":
+			applyBound(schema, param, false, true)
+		}
+	}
+
+	return required
+}
+
+// applyBound sets a lower or upper bound on the schema according to its
+// type: value bounds for numbers, length for strings and size for arrays.
+func applyBound(schema *Schema, param string, lower, exclusive bool) {
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+
+	size := int(n)
+	switch schema.Type {
+	case "integer", "number":
+		if lower {
+			schema.Minimum = &n
+			schema.ExclusiveMinimum = exclusive
+		} else {
+			schema.Maximum = &n
+			schema.ExclusiveMaximum = exclusive
+		}
+	case "string":
+		if lower {
+			schema.MinLength = &size
+		} else {
+			schema.MaxLength = &size
+		}
+	case "array":
+		if lower {
+			schema.MinItems = &size
+		} else {
+			schema.MaxItems = &size
+		}
+	}
+}
+
+// componentName derives a valid component name from a type, stripping
+// package qualifiers from generic type arguments.
+func componentName(t reflect.Type) string {
+	name := packageQualifierReg.ReplaceAllString(t.Name(), "")
+	name = invalidNameCharsReg.ReplaceAllString(name, "_")
+	return strings.Trim(name, "_")
+}
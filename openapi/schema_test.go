@@ -0,0 +1,103 @@
+//go:build unit
+// +build unit
+
+package openapi
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type testNode struct {
+	Id       uuid.UUID   `json:"id"`
+	Name     string      `json:"name" validate:"required,min=2,max=40"`
+	Kind     string      `json:"kind,omitempty" validate:"omitempty,oneof=leaf branch"`
+	Weight   float64     `json:"weight" validate:"gte=0"`
+	Parent   *testNode   `json:"parent"`
+	Children []*testNode `json:"children" validate:"max=10,dive,required"`
+	Internal string      `json:"-"`
+	Created  time.Time   `json:"created_at"`
+	OrgId    string      `path:"org_id"`
+	Limit    *int        `query:"limit" validate:"omitempty,max=100"`
+}
+
+func TestSchemaReflection(t *testing.T) {
+	doc := New(Info{Title: "test", Version: "1"})
+	r := NewReflector(doc)
+
+	ref := r.Schema(reflect.TypeOf(testNode{}))
+	assert.Equal(t, "#/components/schemas/testNode", ref.Ref)
+
+	schema := doc.Components.Schemas["testNode"]
+	assert.Equal(t, []string{"name"}, schema.Required)
+	assert.ElementsMatch(
+		t,
+		[]string{"id", "name", "kind", "weight", "parent", "children", "created_at"},
+		keys(schema.Properties),
+	)
+
+	assert.Equal(t, "uuid", schema.Properties["id"].Format)
+	assert.Equal(t, 2, *schema.Properties["name"].MinLength)
+	assert.Equal(t, 40, *schema.Properties["name"].MaxLength)
+	assert.Equal(t, []any{"leaf", "branch"}, schema.Properties["kind"].Enum)
+	assert.Equal(t, 0.0, *schema.Properties["weight"].Minimum)
+	assert.Equal(t, ref.Ref, schema.Properties["parent"].Ref)
+	assert.Equal(t, 10, *schema.Properties["children"].MaxItems)
+	assert.Equal(t, "date-time", schema.Properties["created_at"].Format)
+}
+
+func TestAnonymousStructsAreInlined(t *testing.T) {
+	doc := New(Info{Title: "test", Version: "1"})
+	r := NewReflector(doc)
+
+	body := r.Schema(reflect.TypeOf(struct {
+		Name  string `json:"name" validate:"required"`
+		Owner struct {
+			Email string `json:"email" validate:"email"`
+		} `json:"owner"`
+		Node *testNode `json:"node"`
+	}{}))
+
+	assert.Empty(t, body.Ref)
+	assert.Equal(t, "object", body.Type)
+	assert.Equal(t, []string{"name"}, body.Required)
+	assert.Equal(t, "email", body.Properties["owner"].Properties["email"].Format)
+	assert.Equal(t, "#/components/schemas/testNode", body.Properties["node"].Ref)
+
+	response := r.Schema(reflect.TypeOf(&struct {
+		Items []struct {
+			Id int `json:"id"`
+		} `json:"items"`
+	}{}))
+	assert.Equal(t, "integer", response.Properties["items"].Items.Properties["id"].Type)
+
+	assert.Equal(t, []string{"testNode"}, keys(doc.Components.Schemas))
+}
+
+func TestParameterReflection(t *testing.T) {
+	r := NewReflector(New(Info{Title: "test", Version: "1"}))
+
+	params := r.Parameters(reflect.TypeOf(testNode{}))
+	assert.Len(t, params, 2)
+
+	assert.Equal(t, "org_id", params[0].Name)
+	assert.Equal(t, "path", params[0].In)
+	assert.True(t, params[0].Required)
+
+	assert.Equal(t, "limit", params[1].Name)
+	assert.Equal(t, "query", params[1].In)
+	assert.False(t, params[1].Required)
+	assert.Equal(t, 100.0, *params[1].Schema.Maximum)
+}
+
+func keys(m map[string]*Schema) []string {
+	ks := make([]string, 0, len(m))
+	for k := range m {
+		ks = append(ks, k)
+	}
+	return ks
+}
@@ -0,0 +1,83 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/dexlabsio/garlic/errors"
+)
+
+// Order sorts the results by a column of the query.
+type Order struct {
+	Column     string `json:"column"`
+	Descending bool   `json:"descending"`
+}
+
+func (o Order) String() string {
+	if o.Descending {
+		return o.Column + " DESC"
+	}
+	return o.Column + " ASC"
+}
+
+// Page selects a slice of the results of a query, either by offset or by
+// an opaque keyset cursor returned by a previous page. Keyset pagination
+// requires OrderBy columns that are never null, the last of them unique,
+// such as the primary key.
+type Page struct {
+	Limit     int
+	Offset    int
+	Cursor    string
+	OrderBy   []Order
+	WithTotal bool
+}
+
+// PageResult describes a page of results. NextCursor is set when there
+// are more results and the page is ordered, and Total when the page was
+// requested WithTotal.
+type PageResult struct {
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset"`
+	HasMore    bool   `json:"has_more"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	Total      *int   `json:"total,omitempty"`
+}
+
+// EncodeCursor encodes the text representation of the order column values
+// of the last result of a page as an opaque cursor.
+func EncodeCursor(values []string) (string, error) {
+	raw, err := json.Marshal(values)
+	if err != nil {
+		return "", errors.PropagateAs(errors.KindSystemError, err, "failed to marshal cursor")
+	}
+
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// DecodeCursor decodes the values of a cursor of a page ordered by size
+// columns. Cursors are sent by clients, so invalid ones fail with
+// KindInvalidRequestError.
+func DecodeCursor(cursor string, size int) ([]string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+
+	var values []string
+	if err == nil {
+		err = json.Unmarshal(raw, &values)
+	}
+
+	if err == nil && len(values) != size {
+		err = fmt.Errorf("cursor has %d values, but the page is ordered by %d columns", len(values), size)
+	}
+
+	if err != nil {
+		return nil, errors.PropagateAs(
+			errors.KindInvalidRequestError,
+			err,
+			"invalid page cursor",
+			errors.Hint("Please use the cursor returned by the previous page without changing the sort order."),
+		)
+	}
+
+	return values, nil
+}
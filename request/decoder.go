@@ -0,0 +1,285 @@
+package request
+
+import (
+	"encoding"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/dexlabsio/garlic/errors"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	PathTag  = "path"
+	QueryTag = "query"
+)
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// Decode fills the struct pointed by dst with the request data and validates
+// it using the validator package. The JSON body is decoded first, then fields
+// tagged with `path:"name"` are read from the URL parameters and fields tagged
+// with `query:"name"` from the query string. Path and query fields may be
+// strings, booleans, numbers, pointers to them, types implementing
+// encoding.TextUnmarshaler such as uuid.UUID, and slices of those for query
+// parameters that repeat.
+//
+// Destinations of a fixed type, like the inputs of typed routes, should be
+// checked once with CheckDecodable, since Decode panics on types it can't
+// decode into.
+func Decode(r *http.Request, dst any) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		panic("destination must be a non-nil pointer to a struct")
+	}
+
+	if err := CheckDecodable(v.Elem().Type()); err != nil {
+		panic(err)
+	}
+
+	if err := decodeBody(r, dst); err != nil {
+		return errors.Propagate(err, "failed to decode request body")
+	}
+
+	if err := decodeParams(r, v.Elem()); err != nil {
+		return errors.Propagate(err, "failed to decode request params")
+	}
+
+	if err := ValidateForm(dst); err != nil {
+		return errors.Propagate(err, "failed to validate request")
+	}
+
+	return nil
+}
+
+// CheckDecodable reports whether Decode can decode requests into values of
+// the type, which must be a struct whose path and query fields have
+// supported types.
+func CheckDecodable(t reflect.Type) error {
+	if t.Kind() != reflect.Struct {
+		return errors.New(
+			errors.KindSystemError,
+			"request destination must be a struct",
+			errors.Context(errors.Field("type", t.String())),
+		)
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if err := CheckDecodable(field.Type); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if field.Tag.Get(PathTag) == "" && field.Tag.Get(QueryTag) == "" {
+			continue
+		}
+
+		if !isParamType(field.Type, true) {
+			return errors.New(
+				errors.KindSystemError,
+				"unsupported request param type",
+				errors.Context(
+					errors.Field("type", t.String()),
+					errors.Field("field", field.Name),
+					errors.Field("field_type", field.Type.String()),
+				),
+			)
+		}
+	}
+
+	return nil
+}
+
+// isParamType reports whether setField can parse params into the type,
+// slices being only accepted at the top level.
+func isParamType(t reflect.Type, slice bool) bool {
+	if t.Implements(textUnmarshalerType) || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.Slice:
+		return slice && isParamType(t.Elem(), false)
+	case reflect.Ptr:
+		return isParamType(t.Elem(), false)
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// decodeBody decodes the JSON request body, if any, into dst. The content
+// length is not trusted since it's unknown for chunked requests, so an empty
+// body is detected by the decoder itself.
+func decodeBody(r *http.Request, dst any) error {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(dst); err != nil && err != io.EOF {
+		return errors.PropagateAs(
+			InvalidRequestError,
+			err,
+			"invalid request body",
+			errors.Hint(
+				"something may be wrong with formatting or the content of the request body",
+			),
+		)
+	}
+
+	return nil
+}
+
+// decodeParams walks the struct fields, including embedded structs, and
+// sets the ones tagged as path or query parameters.
+func decodeParams(r *http.Request, v reflect.Value) error {
+	t := v.Type()
+	query := r.URL.Query()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		value := v.Field(i)
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if field.Anonymous && value.Kind() == reflect.Struct {
+			if err := decodeParams(r, value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if name := field.Tag.Get(PathTag); name != "" {
+			raw := chi.URLParam(r, name)
+			if raw == "" {
+				continue
+			}
+
+			unescaped, err := url.PathUnescape(raw)
+			if err != nil {
+				return errors.PropagateAs(
+					InvalidRequestError,
+					err,
+					"failed to unescape request path param",
+					errors.Hint("We couldn't unescape the request field '%s'", name),
+				)
+			}
+
+			if err := setField(value, []string{unescaped}); err != nil {
+				return errors.PropagateAs(
+					InvalidRequestError,
+					err,
+					"malformed request path param",
+					errors.Hint("Something is wrong with the request field '%s'", name),
+				)
+			}
+		}
+
+		if name := field.Tag.Get(QueryTag); name != "" {
+			raw, ok := query[name]
+			if !ok || len(raw) == 0 {
+				continue
+			}
+
+			if err := setField(value, raw); err != nil {
+				return errors.PropagateAs(
+					InvalidRequestError,
+					err,
+					"malformed request param",
+					errors.Hint("Something is wrong with the request param '%s'", name),
+				)
+			}
+		}
+	}
+
+	return nil
+}
+
+// setField parses the raw values into the given field.
+func setField(v reflect.Value, raw []string) error {
+	if v.Kind() == reflect.Slice && !v.Type().Implements(textUnmarshalerType) {
+		slice := reflect.MakeSlice(v.Type(), len(raw), len(raw))
+		for i, r := range raw {
+			if err := setValue(slice.Index(i), r); err != nil {
+				return err
+			}
+		}
+
+		v.Set(slice)
+		return nil
+	}
+
+	return setValue(v, raw[len(raw)-1])
+}
+
+// setValue parses a single raw value into the given value.
+func setValue(v reflect.Value, raw string) error {
+	if v.Kind() == reflect.Ptr {
+		elem := reflect.New(v.Type().Elem())
+		if err := setValue(elem.Elem(), raw); err != nil {
+			return err
+		}
+
+		v.Set(elem)
+		return nil
+	}
+
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(raw))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(strings.TrimSpace(raw), 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(strings.TrimSpace(raw), 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(raw), v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return errors.New(
+			errors.KindSystemError,
+			"unsupported request param type",
+			errors.Context(errors.Field("type", v.Type().String())),
+		)
+	}
+
+	return nil
+}
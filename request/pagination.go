@@ -0,0 +1,76 @@
+package request
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/dexlabsio/garlic/errors"
+	"github.com/dexlabsio/garlic/pagination"
+)
+
+const (
+	LimitParam  = "limit"
+	OffsetParam = "offset"
+	CursorParam = "cursor"
+
+	// StartParam is the legacy name of OffsetParam, still accepted.
+	StartParam = "start"
+)
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// ParsePage reads the 'limit', 'offset' (or 'start') and 'cursor' query
+// params into a page. The limit defaults to defaultLimit and can't exceed
+// maxLimit. Offsets and cursors are mutually exclusive. The order of the
+// page is left to the caller, usually from ParseSort.
+func ParsePage(r *http.Request, defaultLimit, maxLimit int) (*pagination.Page, error) {
+	query := r.URL.Query()
+	page := &pagination.Page{
+		Limit:  defaultLimit,
+		Cursor: query.Get(CursorParam),
+	}
+
+	if raw := query.Get(LimitParam); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxLimit {
+			return nil, errors.New(
+				InvalidRequestError,
+				"invalid page limit",
+				errors.Context(errors.Field(LimitParam, raw)),
+				errors.Hint("The param '%s' must be a number between 1 and %d", LimitParam, maxLimit),
+			)
+		}
+		page.Limit = limit
+	}
+
+	rawOffset := query.Get(OffsetParam)
+	if rawOffset == "" {
+		rawOffset = query.Get(StartParam)
+	}
+
+	if rawOffset != "" {
+		offset, err := strconv.Atoi(rawOffset)
+		if err != nil || offset < 0 {
+			return nil, errors.New(
+				InvalidRequestError,
+				"invalid page offset",
+				errors.Context(errors.Field(OffsetParam, rawOffset)),
+				errors.Hint("The param '%s' must be a non-negative number", OffsetParam),
+			)
+		}
+
+		if page.Cursor != "" {
+			return nil, errors.New(
+				InvalidRequestError,
+				"page offset and cursor can't be used together",
+				errors.Hint("Please use either '%s' or '%s' to paginate", OffsetParam, CursorParam),
+			)
+		}
+		page.Offset = offset
+	}
+
+	return page, nil
+}
@@ -76,6 +76,8 @@ func ParseResourceString(r *http.Request, param string) (string, error) {
 // It attempts to convert these parameters to integers. If the conversion fails or the parameters are not
 // set, it defaults both 'limit' and 'start' to 0. This function logs debug messages if the parameters
 // are not set or cannot be converted.
+//
+// Deprecated: use ParsePage, which validates the params and supports cursors.
 func ParseParamPagination(r *http.Request) (limit, start int) {
 	l := GetLogger(r)
 	var err error
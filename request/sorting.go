@@ -0,0 +1,78 @@
+package request
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/dexlabsio/garlic/errors"
+	"github.com/dexlabsio/garlic/pagination"
+)
+
+const (
+	SortParam   = "sort"
+	FieldsParam = "fields"
+)
+
+// ParseSort reads the 'sort' query param, a comma separated list of
+// columns where a leading '-' sorts in descending order, like
+// ?sort=-created_at,name. Columns must be in the allowed list, usually
+// derived from the resource with utils.Columns. When the param is not set
+// the defaults are returned.
+func ParseSort(r *http.Request, allowed []string, defaults ...pagination.Order) ([]pagination.Order, error) {
+	raw := r.URL.Query().Get(SortParam)
+	if raw == "" {
+		return defaults, nil
+	}
+
+	orders := []pagination.Order{}
+	for _, term := range strings.Split(raw, ",") {
+		term = strings.TrimSpace(term)
+		column, descending := strings.CutPrefix(term, "-")
+		column = strings.TrimPrefix(column, "+")
+
+		if !slices.Contains(allowed, column) {
+			return nil, unknownFieldError(SortParam, column, allowed)
+		}
+
+		orders = append(orders, pagination.Order{Column: column, Descending: descending})
+	}
+
+	return orders, nil
+}
+
+// ParseFields reads the 'fields' query param, a comma separated list of
+// columns to select, like ?fields=id,name. Columns must be in the allowed
+// list. When the param is not set every allowed column is returned.
+func ParseFields(r *http.Request, allowed []string) ([]string, error) {
+	raw := r.URL.Query().Get(FieldsParam)
+	if raw == "" {
+		return allowed, nil
+	}
+
+	fields := []string{}
+	for _, field := range strings.Split(raw, ",") {
+		field = strings.TrimSpace(field)
+		if !slices.Contains(allowed, field) {
+			return nil, unknownFieldError(FieldsParam, field, allowed)
+		}
+
+		if !slices.Contains(fields, field) {
+			fields = append(fields, field)
+		}
+	}
+
+	return fields, nil
+}
+
+func unknownFieldError(param, field string, allowed []string) error {
+	return errors.New(
+		InvalidRequestError,
+		"unknown field in request param",
+		errors.Context(
+			errors.Field("param", param),
+			errors.Field("field", field),
+		),
+		errors.Hint("Unknown field '%s' in '%s'; allowed values are: %s", field, param, strings.Join(allowed, ", ")),
+	)
+}
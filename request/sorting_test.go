@@ -0,0 +1,58 @@
+//go:build unit
+// +build unit
+
+package request
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dexlabsio/garlic/database"
+	"github.com/dexlabsio/garlic/database/utils"
+	"github.com/dexlabsio/garlic/errors"
+)
+
+type base struct {
+	Id        string    `db:"id"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type user struct {
+	base
+	Name     string `db:"name"`
+	Password string `db:"-"`
+	Session  string
+}
+
+func TestParseSortAndFields(t *testing.T) {
+	allowed := utils.Columns(&user{})
+	assert.Equal(t, []string{"id", "created_at", "name"}, allowed)
+
+	r := httptest.NewRequest("GET", "/users?sort=-created_at,name&fields=id,name", nil)
+
+	orders, err := ParseSort(r, allowed)
+	assert.NoError(t, err)
+	assert.Equal(t, []database.Order{{Column: "created_at", Descending: true}, {Column: "name"}}, orders)
+	assert.Equal(t, ` ORDER BY "created_at" DESC, "name" ASC`, database.OrderBy(orders...))
+
+	fields, err := ParseFields(r, allowed)
+	assert.NoError(t, err)
+	assert.Equal(t, `"id", "name"`, database.ColumnList(fields...))
+
+	r = httptest.NewRequest("GET", "/users", nil)
+	orders, _ = ParseSort(r, allowed, database.Order{Column: "id"})
+	assert.Equal(t, []database.Order{{Column: "id"}}, orders)
+
+	fields, _ = ParseFields(r, allowed)
+	assert.Equal(t, allowed, fields)
+
+	r = httptest.NewRequest("GET", "/users?sort=password&fields=name,drop", nil)
+	_, err = ParseSort(r, allowed)
+	assert.True(t, errors.IsKind(err, InvalidRequestError))
+
+	_, err = ParseFields(r, allowed)
+	assert.True(t, errors.IsKind(err, InvalidRequestError))
+}
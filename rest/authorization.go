@@ -0,0 +1,74 @@
+package rest
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/dexlabsio/garlic/errors"
+)
+
+// Authorization is the requirement declared by a route. The principal must
+// hold every permission and every scope listed.
+type Authorization struct {
+	Permissions []string
+	Scopes      []string
+}
+
+// Authorizer decides whether the request may access a route with the given
+// requirement. Denials are reported as errors, usually of ForbiddenError
+// kind, which are written like any other route error.
+type Authorizer interface {
+	Authorize(r *http.Request, requirement *Authorization) error
+}
+
+var authorizer atomic.Pointer[Authorizer]
+
+// SetAuthorizer sets the authorizer evaluating the requirements of every
+// route. Routes declaring requirements are rejected until one is set.
+func SetAuthorizer(a Authorizer) {
+	authorizer.Store(&a)
+}
+
+// Require declares the permissions needed to access the route. It can be
+// called without permissions to only enforce resource-scoped checks.
+func (route *Route) Require(permissions ...string) *Route {
+	a := route.authorization()
+	a.Permissions = append(a.Permissions, permissions...)
+	return route
+}
+
+// RequireScopes declares the scopes needed to access the route.
+func (route *Route) RequireScopes(scopes ...string) *Route {
+	a := route.authorization()
+	a.Scopes = append(a.Scopes, scopes...)
+	return route
+}
+
+func (route *Route) authorization() *Authorization {
+	if route.Authorization == nil {
+		route.Authorization = &Authorization{}
+	}
+	return route.Authorization
+}
+
+// authorize evaluates the route requirement, failing closed when no
+// authorizer was set.
+func (route *Route) authorize(r *http.Request) error {
+	if route.Authorization == nil {
+		return nil
+	}
+
+	a := authorizer.Load()
+	if a == nil || *a == nil {
+		return errors.New(
+			errors.KindSystemError,
+			"route requires authorization but no authorizer is set",
+			errors.Context(
+				errors.Field("method", route.Method),
+				errors.Field("pattern", route.Pattern),
+			),
+		)
+	}
+
+	return (*a).Authorize(r, route.Authorization)
+}
@@ -0,0 +1,129 @@
+package rest
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrConfigInvalidClientAuth = errors.New(
+		"invalid ClientAuth; valid options are [none, request, require, verify_if_given, require_and_verify]",
+	)
+	ErrConfigMissingClientCA = errors.New("ClientAuth verifies client certificates but no ClientCAFile is set")
+)
+
+type ClientAuth string
+
+const (
+	ClientAuthNone             ClientAuth = "none"
+	ClientAuthRequest          ClientAuth = "request"
+	ClientAuthRequire          ClientAuth = "require"
+	ClientAuthVerifyIfGiven    ClientAuth = "verify_if_given"
+	ClientAuthRequireAndVerify ClientAuth = "require_and_verify"
+)
+
+var (
+	ClientAuths = map[ClientAuth]tls.ClientAuthType{
+		ClientAuthNone:             tls.NoClientCert,
+		ClientAuthRequest:          tls.RequestClientCert,
+		ClientAuthRequire:          tls.RequireAnyClientCert,
+		ClientAuthVerifyIfGiven:    tls.VerifyClientCertIfGiven,
+		ClientAuthRequireAndVerify: tls.RequireAndVerifyClientCert,
+	}
+)
+
+// Config describes how the HTTP server accepts and
+// serves connections.
+type Config struct {
+	ReadTimeout       time.Duration `mapstructure:"read_timeout" yaml:"read_timeout"`
+	ReadHeaderTimeout time.Duration `mapstructure:"read_header_timeout" yaml:"read_header_timeout"`
+	WriteTimeout      time.Duration `mapstructure:"write_timeout" yaml:"write_timeout"`
+	IdleTimeout       time.Duration `mapstructure:"idle_timeout" yaml:"idle_timeout"`
+	MaxHeaderBytes    int           `mapstructure:"max_header_bytes" yaml:"max_header_bytes"`
+	ShutdownTimeout   time.Duration `mapstructure:"shutdown_timeout" yaml:"shutdown_timeout"`
+	TLS               *TLSConfig    `mapstructure:"tls" yaml:"tls"`
+}
+
+// TLSConfig describes the certificates used to serve HTTPS. Certificates
+// are read from disk and reloaded every ReloadInterval when they change,
+// so rotated certificates are picked up without restarting the server.
+// Setting ClientCAFile and ClientAuth enables mTLS.
+type TLSConfig struct {
+	Enabled        bool          `mapstructure:"enabled" yaml:"enabled"`
+	CertFile       string        `mapstructure:"cert_file" yaml:"cert_file"`
+	KeyFile        string        `mapstructure:"key_file" yaml:"key_file"`
+	ClientCAFile   string        `mapstructure:"client_ca_file" yaml:"client_ca_file"`
+	ClientAuth     ClientAuth    `mapstructure:"client_auth" yaml:"client_auth"`
+	ReloadInterval time.Duration `mapstructure:"reload_interval" yaml:"reload_interval"`
+}
+
+func Defaults() *Config {
+	return &Config{
+		ReadTimeout:       30 * time.Second,
+		ReadHeaderTimeout: 10 * time.Second,
+		WriteTimeout:      60 * time.Second,
+		IdleTimeout:       120 * time.Second,
+		MaxHeaderBytes:    1 << 20,
+		ShutdownTimeout:   DefaultShutdownTimeout,
+		TLS:               TLSConfigDefaults(),
+	}
+}
+
+func TLSConfigDefaults() *TLSConfig {
+	return &TLSConfig{
+		Enabled:        false,
+		ClientAuth:     ClientAuthNone,
+		ReloadInterval: time.Minute,
+	}
+}
+
+// UnmarshalJSON unmarshals a JSON string into a ClientAuth and checks
+// if it's a valid supported option
+func (c *ClientAuth) UnmarshalJSON(data []byte) error {
+	var mode string
+	if err := json.Unmarshal(data, &mode); err != nil {
+		return fmt.Errorf("[rest] failed unmarshalling server config: %w", err)
+	}
+
+	ca := ClientAuth(mode)
+	if _, valid := ClientAuths[ca]; !valid {
+		return fmt.Errorf("[rest] failed validating server config: %w", ErrConfigInvalidClientAuth)
+	}
+
+	*c = ca
+	return nil
+}
+
+// Validate checks that the TLS options are consistent: client certificates
+// can only be verified against the CAs of a ClientCAFile.
+func (c *TLSConfig) Validate() error {
+	clientAuth, err := c.ClientAuth.Type()
+	if err != nil {
+		return err
+	}
+
+	verifies := clientAuth == tls.VerifyClientCertIfGiven || clientAuth == tls.RequireAndVerifyClientCert
+	if verifies && c.ClientCAFile == "" {
+		return ErrConfigMissingClientCA
+	}
+
+	return nil
+}
+
+// Type converts the ClientAuth into the crypto/tls representation.
+// An empty value is treated as ClientAuthNone.
+func (c ClientAuth) Type() (tls.ClientAuthType, error) {
+	if c == "" {
+		return tls.NoClientCert, nil
+	}
+
+	t, valid := ClientAuths[c]
+	if !valid {
+		return tls.NoClientCert, ErrConfigInvalidClientAuth
+	}
+
+	return t, nil
+}
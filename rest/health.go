@@ -0,0 +1,26 @@
+package rest
+
+import (
+	"context"
+
+	"github.com/dexlabsio/garlic/errors"
+	"github.com/dexlabsio/garlic/health"
+)
+
+// MountHealth serves the liveness and readiness probes of the given health
+// registry on this server. The readiness probe also reports the server as
+// down while it's draining connections during shutdown. The legacy /health
+// route answers with the readiness report.
+func (s *Server) MountHealth(h *health.Health) {
+	ready := h.ReadyHandler(health.Func("server", func(context.Context) error {
+		if !s.Ready() {
+			return errors.New(errors.KindSystemError, "server is not accepting traffic")
+		}
+
+		return nil
+	}))
+
+	s.root.Get(health.LivePath, h.LiveHandler())
+	s.root.Get(health.ReadyPath, ready)
+	s.root.Get("/health", ready)
+}
@@ -0,0 +1,21 @@
+package rest
+
+import (
+	"github.com/dexlabsio/garlic/monitoring"
+)
+
+const MetricsPath = "/metrics"
+
+// MountMetrics serves the metrics gathered by the given registry on this
+// server. Use NewAdminServer to expose them on a separate bind address.
+func (s *Server) MountMetrics(registry *monitoring.Registry) {
+	s.root.Handle(MetricsPath, registry.Handler())
+}
+
+// NewAdminServer creates a server that only exposes operational endpoints,
+// meant to listen on a private admin bind address apart from the public API.
+func NewAdminServer(name string, registry *monitoring.Registry) *Server {
+	srv := NewServer(name)
+	srv.MountMetrics(registry)
+	return srv
+}
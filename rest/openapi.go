@@ -0,0 +1,179 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/dexlabsio/garlic/errors"
+	"github.com/dexlabsio/garlic/openapi"
+)
+
+const OpenAPIPath = "/openapi.json"
+
+var (
+	routeParamReg  = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+	operationIdReg = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+	noContentType  = reflect.TypeOf(NoContent{})
+)
+
+// MountOpenAPI serves the OpenAPI specification of the routes registered in
+// this server. The document is generated on the first request, so every app
+// must be registered before the server starts listening.
+func (s *Server) MountOpenAPI(info openapi.Info) {
+	var (
+		once sync.Once
+		doc  *openapi.Document
+	)
+
+	s.root.Get(OpenAPIPath, func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() {
+			doc = s.OpenAPI(info)
+		})
+
+		WriteResponse(http.StatusOK, doc).Must(w)
+	})
+}
+
+// OpenAPI generates an OpenAPI 3 document out of the routes registered
+// through RegisterApp and their metadata.
+func (s *Server) OpenAPI(info openapi.Info) *openapi.Document {
+	doc := openapi.New(info)
+	reflector := openapi.NewReflector(doc)
+	errorSchema := reflector.Schema(reflect.TypeOf(errors.DTO{}))
+
+	for _, route := range s.routes {
+		path := routeParamReg.ReplaceAllString(route.Pattern, "{$1}")
+
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = &openapi.PathItem{}
+			doc.Paths[path] = item
+		}
+
+		(*item)[strings.ToLower(route.Method)] = operation(reflector, errorSchema, route, path)
+	}
+
+	return doc
+}
+
+// operation documents a single route.
+func operation(reflector *openapi.Reflector, errorSchema *openapi.Schema, route *Route, path string) *openapi.Operation {
+	op := &openapi.Operation{
+		OperationID: strings.Trim(operationIdReg.ReplaceAllString(strings.ToLower(route.Method+"_"+path), "_"), "_"),
+		Summary:     route.Summary,
+		Description: route.Description,
+		Tags:        route.Tags,
+		Responses:   map[string]*openapi.Response{},
+	}
+
+	kinds := slices.Clone(route.Errors)
+
+	if route.Authorization != nil {
+		op.Permissions = route.Authorization.Permissions
+		op.Scopes = route.Authorization.Scopes
+		kinds = append(kinds, errors.KindAuthError, errors.KindForbiddenError)
+	}
+
+	if route.RequestType != nil {
+		op.Parameters = reflector.Parameters(route.RequestType)
+		kinds = append(kinds, errors.KindInvalidRequestError, errors.KindValidationError)
+
+		hasBody := route.Method != http.MethodGet && route.Method != http.MethodDelete
+		if hasBody && reflector.HasBody(route.RequestType) {
+			op.RequestBody = &openapi.RequestBody{
+				Required: true,
+				Content:  jsonContent(reflector.Schema(route.RequestType)),
+			}
+		}
+	}
+
+	// Path params that are not declared by the request type are documented
+	// as plain strings, since that's how chi hands them to the handlers.
+	for _, match := range routeParamReg.FindAllStringSubmatch(route.Pattern, -1) {
+		name := match[1]
+		declared := slices.ContainsFunc(op.Parameters, func(p *openapi.Parameter) bool {
+			return p.In == "path" && p.Name == name
+		})
+
+		if !declared {
+			op.Parameters = append(op.Parameters, &openapi.Parameter{
+				Name:     name,
+				In:       "path",
+				Required: true,
+				Schema:   &openapi.Schema{Type: "string"},
+			})
+		}
+	}
+
+	statusCode := route.StatusCode
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+
+	switch route.ResponseType {
+	case nil:
+		op.Responses[strconv.Itoa(statusCode)] = &openapi.Response{
+			Description: http.StatusText(statusCode),
+		}
+	case noContentType:
+		op.Responses[strconv.Itoa(http.StatusNoContent)] = &openapi.Response{
+			Description: http.StatusText(http.StatusNoContent),
+		}
+	default:
+		op.Responses[strconv.Itoa(statusCode)] = &openapi.Response{
+			Description: http.StatusText(statusCode),
+			Content:     jsonContent(reflector.Schema(route.ResponseType)),
+		}
+	}
+
+	// Any route may fail unexpectedly, which WriteError reports as a system error.
+	kinds = append(kinds, errors.KindSystemError)
+	for statusCode, description := range errorResponses(kinds) {
+		op.Responses[strconv.Itoa(statusCode)] = &openapi.Response{
+			Description: description,
+			Content:     jsonContent(errorSchema),
+		}
+	}
+
+	return op
+}
+
+// errorResponses groups error kinds by their HTTP status code and describes
+// each group with the kinds that may be returned.
+func errorResponses(kinds []*errors.Kind) map[int]string {
+	descriptions := map[int][]string{}
+	seen := map[string]bool{}
+
+	for _, kind := range kinds {
+		if seen[kind.Code] {
+			continue
+		}
+		seen[kind.Code] = true
+
+		statusCode := kind.StatusCode()
+		descriptions[statusCode] = append(
+			descriptions[statusCode],
+			fmt.Sprintf("%s (%s): %s", kind.Name, kind.Code, kind.Description),
+		)
+	}
+
+	responses := make(map[int]string, len(descriptions))
+	for statusCode, lines := range descriptions {
+		responses[statusCode] = strings.Join(lines, "\n")
+	}
+
+	return responses
+}
+
+// jsonContent wraps a schema as the JSON content of a request or response.
+func jsonContent(schema *openapi.Schema) map[string]*openapi.MediaType {
+	return map[string]*openapi.MediaType{
+		"application/json": {Schema: schema},
+	}
+}
@@ -0,0 +1,72 @@
+package rest
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/dexlabsio/garlic/pagination"
+	"github.com/dexlabsio/garlic/request"
+)
+
+// Page is the envelope of paginated responses, carrying the items of the
+// page and the metadata needed to fetch the next ones.
+type Page[T any] struct {
+	Items []T                    `json:"items"`
+	Page  *pagination.PageResult `json:"page"`
+}
+
+// WritePage creates a 200 response with the page envelope and a Link
+// header pointing to the first, previous and next pages, built from the
+// URL of the request.
+func WritePage[T any](r *http.Request, items []T, result *pagination.PageResult) *Response {
+	if items == nil {
+		items = []T{}
+	}
+
+	response := WriteResponse(http.StatusOK, Page[T]{Items: items, Page: result})
+	response.Headers = http.Header{}
+	if link := pageLinks(r, result); link != "" {
+		response.Headers.Set("Link", link)
+	}
+
+	return response
+}
+
+// pageLinks builds the value of the Link header of a page (RFC 8288).
+// Cursor pages have no previous link, since cursors only move forward
+// and their offset is always zero.
+func pageLinks(r *http.Request, result *pagination.PageResult) string {
+	link := func(rel string, set func(q url.Values)) string {
+		u := *r.URL
+		q := u.Query()
+		q.Del(request.OffsetParam)
+		q.Del(request.StartParam)
+		q.Del(request.CursorParam)
+		q.Set(request.LimitParam, strconv.Itoa(result.Limit))
+		set(q)
+		u.RawQuery = q.Encode()
+		return "<" + u.RequestURI() + `>; rel="` + rel + `"`
+	}
+
+	links := []string{link("first", func(url.Values) {})}
+
+	if result.Offset > 0 {
+		links = append(links, link("prev", func(q url.Values) {
+			q.Set(request.OffsetParam, strconv.Itoa(max(result.Offset-result.Limit, 0)))
+		}))
+	}
+
+	if result.HasMore {
+		links = append(links, link("next", func(q url.Values) {
+			if result.NextCursor != "" {
+				q.Set(request.CursorParam, result.NextCursor)
+			} else {
+				q.Set(request.OffsetParam, strconv.Itoa(result.Offset+result.Limit))
+			}
+		}))
+	}
+
+	return strings.Join(links, ", ")
+}
@@ -0,0 +1,32 @@
+//go:build unit
+// +build unit
+
+package rest
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dexlabsio/garlic/database"
+)
+
+func TestWritePage(t *testing.T) {
+	r := httptest.NewRequest("GET", "/items?offset=20&limit=10&sort=name", nil)
+	response := WritePage(r, []string{"a"}, &database.PageResult{Limit: 10, Offset: 20, HasMore: true})
+
+	assert.Equal(
+		t,
+		`</items?limit=10&sort=name>; rel="first", `+
+			`</items?limit=10&offset=10&sort=name>; rel="prev", `+
+			`</items?limit=10&offset=30&sort=name>; rel="next"`,
+		response.Headers.Get("Link"),
+	)
+
+	r = httptest.NewRequest("GET", "/items", nil)
+	response = WritePage[string](r, nil, &database.PageResult{Limit: 20, HasMore: true, NextCursor: "abc"})
+
+	assert.Equal(t, `</items?limit=20>; rel="first", </items?cursor=abc&limit=20>; rel="next"`, response.Headers.Get("Link"))
+	assert.Equal(t, []string{}, response.Payload.(Page[string]).Items)
+}
@@ -15,6 +15,7 @@ type PayloadMessage struct {
 type Response struct {
 	StatusCode int
 	Payload    any
+	Headers    http.Header
 }
 
 var (
@@ -41,7 +42,10 @@ var (
 )
 
 func (r *Response) Must(w http.ResponseWriter) {
-	w.Header().Set("Context-Type", "application/json")
+	for key, values := range r.Headers {
+		w.Header()[key] = values
+	}
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(r.StatusCode)
 	if err := json.NewEncoder(w).Encode(r.Payload); err != nil {
 		panic(fmt.Sprintf("Failed to encode response %s", err))
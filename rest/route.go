@@ -2,6 +2,8 @@ package rest
 
 import (
 	"net/http"
+	"reflect"
+	"strings"
 
 	chi "github.com/go-chi/chi/v5"
 
@@ -11,15 +13,78 @@ import (
 
 type Routes []*Route
 
+// Middleware wraps a handler, in the same format accepted by chi.
+type Middleware = func(http.Handler) http.Handler
+
 type Route struct {
 	Method  string
 	Pattern string
 	Fn      func(http.ResponseWriter, *http.Request) error
+
+	// Middlewares are applied only to this route, after the app ones.
+	Middlewares []Middleware
+
+	// Authorization is checked by the authorizer before calling Fn.
+	Authorization *Authorization
+
+	// Metadata used to document the route in the OpenAPI specification.
+	Summary      string
+	Description  string
+	Tags         []string
+	RequestType  reflect.Type
+	ResponseType reflect.Type
+	StatusCode   int
+	Errors       []*errors.Kind
+}
+
+// With attaches middlewares to this route only, such as authentication,
+// rate limiting or body size limits.
+func (route *Route) With(middlewares ...Middleware) *Route {
+	route.Middlewares = append(route.Middlewares, middlewares...)
+	return route
+}
+
+// WithSummary sets a short summary and an optional longer description
+// of what the route does.
+func (route *Route) WithSummary(summary string, description ...string) *Route {
+	route.Summary = summary
+	route.Description = strings.Join(description, "\n")
+	return route
+}
+
+// WithTags groups the route under the given tags.
+func (route *Route) WithTags(tags ...string) *Route {
+	route.Tags = append(route.Tags, tags...)
+	return route
+}
+
+// WithRequest declares the type decoded from the request. Fields tagged with
+// `path` and `query` are documented as parameters and the others as the body.
+func (route *Route) WithRequest(sample any) *Route {
+	route.RequestType = reflect.TypeOf(sample)
+	return route
+}
+
+// WithResponse declares the status code and the type of successful responses.
+func (route *Route) WithResponse(statusCode int, sample any) *Route {
+	route.StatusCode = statusCode
+	route.ResponseType = reflect.TypeOf(sample)
+	return route
+}
+
+// WithErrors declares the error kinds the route may answer with.
+func (route *Route) WithErrors(kinds ...*errors.Kind) *Route {
+	route.Errors = append(route.Errors, kinds...)
+	return route
 }
 
 func (route *Route) Handler() func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
-		err := route.Fn(w, r)
+		err := route.authorize(r)
+		if err == nil {
+			err = route.Fn(w, r)
+		}
+
 		if err != nil {
 			ctx := r.Context()
 			l := logging.GetLoggerFromContext(ctx)
@@ -36,31 +101,71 @@ func (route *Route) Handler() func(w http.ResponseWriter, r *http.Request) {
 }
 
 func Get(url string, f func(http.ResponseWriter, *http.Request) error) *Route {
-	return &Route{http.MethodGet, url, f}
+	return &Route{Method: http.MethodGet, Pattern: url, Fn: f}
 }
 
 func Post(url string, f func(http.ResponseWriter, *http.Request) error) *Route {
-	return &Route{http.MethodPost, url, f}
+	return &Route{Method: http.MethodPost, Pattern: url, Fn: f}
 }
 
 func Put(url string, f func(http.ResponseWriter, *http.Request) error) *Route {
-	return &Route{http.MethodPut, url, f}
+	return &Route{Method: http.MethodPut, Pattern: url, Fn: f}
 }
 
 func Patch(url string, f func(http.ResponseWriter, *http.Request) error) *Route {
-	return &Route{http.MethodPatch, url, f}
+	return &Route{Method: http.MethodPatch, Pattern: url, Fn: f}
 }
 
 func Delete(url string, f func(http.ResponseWriter, *http.Request) error) *Route {
-	return &Route{http.MethodDelete, url, f}
+	return &Route{Method: http.MethodDelete, Pattern: url, Fn: f}
 }
 
 type App interface {
 	Routes() Routes
 }
 
+// PrefixedApp is an App whose routes are mounted under a path prefix.
+type PrefixedApp interface {
+	App
+	Prefix() string
+}
+
+// MiddlewareApp is an App declaring middlewares applied to all of its routes.
+type MiddlewareApp interface {
+	App
+	Middlewares() []Middleware
+}
+
+// RegisterApp registers the routes of the app in the router. App middlewares
+// are applied in an isolated group, so several apps with different policies
+// can share the same router, followed by the middlewares of each route.
 func RegisterApp(r chi.Router, app App) {
-	for _, route := range app.Routes() {
-		r.MethodFunc(route.Method, route.Pattern, route.Handler())
+	r.Group(func(r chi.Router) {
+		if mApp, ok := app.(MiddlewareApp); ok {
+			r.Use(mApp.Middlewares()...)
+		}
+
+		for _, route := range mountedRoutes(app) {
+			r.With(route.Middlewares...).MethodFunc(route.Method, route.Pattern, route.Handler())
+		}
+	})
+}
+
+// mountedRoutes returns copies of the app routes with the app prefix, if
+// any, joined to their patterns.
+func mountedRoutes(app App) Routes {
+	prefix := ""
+	if pApp, ok := app.(PrefixedApp); ok {
+		prefix = strings.TrimRight(pApp.Prefix(), "/")
 	}
+
+	appRoutes := app.Routes()
+	routes := make(Routes, 0, len(appRoutes))
+	for _, route := range appRoutes {
+		mounted := *route
+		mounted.Pattern = prefix + route.Pattern
+		routes = append(routes, &mounted)
+	}
+
+	return routes
 }
@@ -0,0 +1,86 @@
+//go:build unit
+// +build unit
+
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	chi "github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+type testApp struct {
+	prefix string
+	mark   string
+}
+
+func (a *testApp) Prefix() string {
+	return a.prefix
+}
+
+func (a *testApp) Middlewares() []Middleware {
+	return []Middleware{header(a.mark)}
+}
+
+func (a *testApp) Routes() Routes {
+	return Routes{
+		Get("/ping", func(w http.ResponseWriter, r *http.Request) error {
+			_, err := w.Write([]byte(r.Header.Get("X-Trail")))
+			return err
+		}).With(header("route")),
+	}
+}
+
+// header appends the mark to the X-Trail request header so tests can
+// assert which middlewares ran and in which order.
+func header(mark string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			trail := strings.Trim(r.Header.Get("X-Trail")+","+mark, ",")
+			r.Header.Set("X-Trail", trail)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestRegisterApp(t *testing.T) {
+	r := chi.NewRouter()
+	RegisterApp(r, &testApp{prefix: "/v1/", mark: "v1"})
+	RegisterApp(r, &testApp{prefix: "/v2", mark: "v2"})
+
+	cases := []struct {
+		path           string
+		expectedStatus int
+		expectedTrail  string
+	}{
+		{path: "/v1/ping", expectedStatus: http.StatusOK, expectedTrail: "v1,route"},
+		{path: "/v2/ping", expectedStatus: http.StatusOK, expectedTrail: "v2,route"},
+		{path: "/ping", expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			if tc.expectedStatus == http.StatusOK {
+				assert.Equal(t, tc.expectedTrail, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestRouteRequirements(t *testing.T) {
+	route := Get("/ping", func(w http.ResponseWriter, r *http.Request) error { return nil }).
+		RequireScopes("read").
+		Require("pings:read").
+		Require("pings:list")
+
+	assert.Equal(t, []string{"pings:read", "pings:list"}, route.Authorization.Permissions)
+	assert.Equal(t, []string{"read"}, route.Authorization.Scopes)
+}
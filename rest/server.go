@@ -2,61 +2,232 @@ package rest
 
 import (
 	"context"
+	"crypto/tls"
+	"net"
 	"net/http"
+	"sync/atomic"
+	"time"
 
+	"github.com/dexlabsio/garlic/errors"
 	"github.com/dexlabsio/garlic/logging"
 	chi "github.com/go-chi/chi/v5"
 	"go.uber.org/zap"
 )
 
+// DefaultShutdownTimeout is the time the server waits for in-flight
+// requests to finish after the context is canceled.
+const DefaultShutdownTimeout = 30 * time.Second
+
 var servers map[string]*Server
 
+// Hook is a function executed around the server shutdown. It receives a
+// context bounded by the shutdown timeout: before hooks share it with the
+// drain, while after hooks get a deadline of their own.
+type Hook func(ctx context.Context) error
+
 type Server struct {
 	Name string
-	root chi.Router
+
+	// ShutdownTimeout is the maximum time the server waits for in-flight
+	// requests to drain before closing the remaining connections. It's set
+	// from the shutdown_timeout of the config by Configure, and zero means
+	// DefaultShutdownTimeout.
+	ShutdownTimeout time.Duration
+
+	config         *Config
+	root           chi.Router
+	routes         Routes
+	ready          atomic.Bool
+	beforeShutdown []Hook
+	afterShutdown  []Hook
 }
 
 func NewServer(name string) *Server {
 	router := chi.NewRouter()
 
 	return &Server{
-		Name: name,
-		root: router,
+		Name:            name,
+		ShutdownTimeout: DefaultShutdownTimeout,
+		config:          Defaults(),
+		root:            router,
 	}
 }
 
+// Configure replaces the server configuration. It must be called before
+// Listen, as the configuration is only read when the server starts. A nil
+// config restores the defaults.
+func (s *Server) Configure(config *Config) *Server {
+	if config == nil {
+		config = Defaults()
+	}
+
+	s.config = config
+	s.ShutdownTimeout = config.ShutdownTimeout
+	return s
+}
+
 func (s *Server) Router() chi.Router {
 	return s.root
 }
 
+// RegisterApp registers the routes of the app in the server router and keeps
+// track of them, so they can be documented in the OpenAPI specification.
+func (s *Server) RegisterApp(app App) {
+	s.routes = append(s.routes, mountedRoutes(app)...)
+	RegisterApp(s.root, app)
+}
+
+// Routes returns every route registered through RegisterApp.
+func (s *Server) Routes() Routes {
+	return s.routes
+}
+
+// Ready reports whether the server is accepting traffic. It becomes true once
+// the server starts listening and flips back to false as soon as the server
+// starts draining connections during shutdown.
+func (s *Server) Ready() bool {
+	return s.ready.Load()
+}
+
+// BeforeShutdown registers hooks executed right after the server is marked
+// as not ready and before the HTTP server starts draining connections.
+func (s *Server) BeforeShutdown(hooks ...Hook) {
+	s.beforeShutdown = append(s.beforeShutdown, hooks...)
+}
+
+// AfterShutdown registers hooks executed once the HTTP server has finished
+// draining connections, e.g. to close database pools used by handlers.
+// They're given a fresh shutdown timeout, so they can still run when the
+// drain used all of it.
+func (s *Server) AfterShutdown(hooks ...Hook) {
+	s.afterShutdown = append(s.afterShutdown, hooks...)
+}
+
 // Listen starts an HTTP server on the specified bind address and listens for incoming requests.
-// It runs in a separate goroutine and returns a channel to report errors. The server will log
-// the bind address and continue running until the context is canceled, at which point it will
-// send the context's error to the error channel. Note that http.ListenAndServe does not stop
-// immediately upon context cancellation; it stops only on an error or when the process exits.
+// It runs in a separate goroutine and returns a channel to report errors. When TLS is enabled
+// the server serves HTTPS and keeps reloading the certificates from disk. When the context is
+// canceled the server is marked as not ready, the shutdown hooks are executed and in-flight
+// requests are given up to ShutdownTimeout to finish. The channel then receives
+// the context's error, or the shutdown error if draining failed, and is closed.
 func (s *Server) Listen(ctx context.Context, bind string) <-chan error {
-	l := logging.Global()
+	l := logging.Global().With(zap.String("bind", bind), zap.String("server", s.Name))
 	errCh := make(chan error, 1)
 
-	// This version immediately stops reporting when the context is canceled,
-	// but note that http.ListenAndServe itself doesn't stop immediately—it
-	// stops only on an error or when the process exits.
+	srv := &http.Server{
+		Addr:              bind,
+		Handler:           s.Router(),
+		ReadTimeout:       s.config.ReadTimeout,
+		ReadHeaderTimeout: s.config.ReadHeaderTimeout,
+		WriteTimeout:      s.config.WriteTimeout,
+		IdleTimeout:       s.config.IdleTimeout,
+		MaxHeaderBytes:    s.config.MaxHeaderBytes,
+	}
+
 	go func() {
 		defer close(errCh)
 
-		go func() {
-			l.Info("Listening.", zap.String("bind", bind), zap.String("server", s.Name))
-			err := http.ListenAndServe(bind, s.Router())
+		ln, err := s.listener(ctx, bind, l)
+		if err != nil {
 			errCh <- err
+			return
+		}
+
+		serveErr := make(chan error, 1)
+		go func() {
+			l.Info("Listening.")
+			s.ready.Store(true)
+			serveErr <- srv.Serve(ln)
 		}()
 
-		<-ctx.Done()
+		select {
+		case err := <-serveErr:
+			s.ready.Store(false)
+			errCh <- err
+			return
+		case <-ctx.Done():
+		}
+
+		if err := s.shutdown(srv, l); err != nil {
+			errCh <- err
+			return
+		}
+
 		errCh <- ctx.Err()
 	}()
 
 	return errCh
 }
 
+// listener binds the given address and, when TLS is enabled, wraps the
+// listener with a TLS configuration backed by a certificate reloader.
+func (s *Server) listener(ctx context.Context, bind string, l *zap.Logger) (net.Listener, error) {
+	var reloader *certificateReloader
+	if s.config.TLS != nil && s.config.TLS.Enabled {
+		var err error
+		if reloader, err = newCertificateReloader(s.config.TLS); err != nil {
+			return nil, errors.Propagate(err, "failed to configure server TLS")
+		}
+	}
+
+	ln, err := net.Listen("tcp", bind)
+	if err != nil {
+		return nil, errors.PropagateAs(errors.KindSystemError, err, "failed to bind server address")
+	}
+
+	if reloader == nil {
+		return ln, nil
+	}
+
+	go reloader.Watch(ctx, l)
+	return tls.NewListener(ln, reloader.TLSConfig()), nil
+}
+
+// shutdown flips the readiness flag, runs the registered hooks and gracefully
+// drains the HTTP server within the configured shutdown timeout.
+func (s *Server) shutdown(srv *http.Server, l *zap.Logger) error {
+	timeout := s.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = DefaultShutdownTimeout
+	}
+
+	s.ready.Store(false)
+	l.Info("Draining connections.", zap.Duration("shutdown_timeout", timeout))
+
+	// The parent context is already canceled at this point, so the drain
+	// deadline must be derived from a fresh context.
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	runHooks(ctx, l, "before", s.beforeShutdown)
+
+	var shutdownErr error
+	if err := srv.Shutdown(ctx); err != nil {
+		shutdownErr = errors.PropagateAs(errors.KindSystemError, err, "failed to gracefully shutdown server")
+		l.Error("Failed to drain connections, closing them.", errors.Zap(shutdownErr))
+		_ = srv.Close()
+	}
+
+	// A drain reaching the deadline would leave the after hooks with an
+	// expired context, so they get their own.
+	afterCtx, afterCancel := context.WithTimeout(context.Background(), timeout)
+	defer afterCancel()
+
+	runHooks(afterCtx, l, "after", s.afterShutdown)
+
+	l.Info("Server stopped.")
+	return shutdownErr
+}
+
+// runHooks executes every hook in order. Hook failures are logged and do not
+// prevent the remaining hooks from running.
+func runHooks(ctx context.Context, l *zap.Logger, stage string, hooks []Hook) {
+	for _, hook := range hooks {
+		if err := hook(ctx); err != nil {
+			l.Error("Shutdown hook failed.", zap.String("stage", stage), errors.Zap(err))
+		}
+	}
+}
+
 // GetServer implements a multiton of servers
 func GetServer(name string) *Server {
 	if servers == nil {
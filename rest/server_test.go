@@ -0,0 +1,44 @@
+//go:build unit
+// +build unit
+
+package rest
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestAfterShutdownHooksOutliveTheDrain(t *testing.T) {
+	server := NewServer("test")
+	server.ShutdownTimeout = 50 * time.Millisecond
+
+	var afterErr error
+	server.AfterShutdown(func(ctx context.Context) error {
+		afterErr = ctx.Err()
+		return nil
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	// The request outlives the shutdown timeout, so the drain reaches it.
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})}
+	go func() { _ = srv.Serve(ln) }()
+	go func() { _, _ = http.Get("http://" + ln.Addr().String()) }()
+	<-started
+
+	assert.Error(t, server.shutdown(srv, zap.NewNop()))
+	assert.NoError(t, afterErr)
+}
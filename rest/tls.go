@@ -0,0 +1,180 @@
+package rest
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/dexlabsio/garlic/errors"
+	"go.uber.org/zap"
+)
+
+// certificateReloader keeps the server certificate and the client CA pool
+// loaded from disk and swaps them whenever the files change.
+type certificateReloader struct {
+	config     *TLSConfig
+	clientAuth tls.ClientAuthType
+
+	mu          sync.RWMutex
+	certificate *tls.Certificate
+	clientCAs   *x509.CertPool
+	modTimes    map[string]time.Time
+}
+
+func newCertificateReloader(config *TLSConfig) (*certificateReloader, error) {
+	if err := config.Validate(); err != nil {
+		return nil, errors.PropagateAs(
+			errors.KindSystemError,
+			err,
+			"invalid TLS config",
+			errors.Context(
+				errors.Field("client_auth", config.ClientAuth),
+				errors.Field("client_ca_file", config.ClientCAFile),
+			),
+		)
+	}
+
+	// Validated above.
+	clientAuth, _ := config.ClientAuth.Type()
+
+	r := &certificateReloader{
+		config:     config,
+		clientAuth: clientAuth,
+		modTimes:   map[string]time.Time{},
+	}
+
+	if err := r.reload(); err != nil {
+		return nil, errors.Propagate(err, "failed to load TLS certificates")
+	}
+
+	return r, nil
+}
+
+// TLSConfig returns a tls.Config that resolves the current certificates
+// on every handshake.
+func (r *certificateReloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+
+			return &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				NextProtos:   []string{"h2", "http/1.1"},
+				Certificates: []tls.Certificate{*r.certificate},
+				ClientAuth:   r.clientAuth,
+				ClientCAs:    r.clientCAs,
+			}, nil
+		},
+	}
+}
+
+// Watch polls the certificate files every ReloadInterval and reloads them
+// when any modification time changes. It returns when the context is done.
+// Reload failures are logged and the previous certificates are kept.
+func (r *certificateReloader) Watch(ctx context.Context, l *zap.Logger) {
+	if r.config.ReloadInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(r.config.ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !r.changed() {
+				continue
+			}
+
+			if err := r.reload(); err != nil {
+				l.Error("Failed to reload TLS certificates, keeping previous ones.", errors.Zap(err))
+				continue
+			}
+
+			l.Info("TLS certificates reloaded.")
+		}
+	}
+}
+
+// files returns the paths that are tracked by the reloader.
+func (r *certificateReloader) files() []string {
+	files := []string{r.config.CertFile, r.config.KeyFile}
+	if r.config.ClientCAFile != "" {
+		files = append(files, r.config.ClientCAFile)
+	}
+
+	return files
+}
+
+// changed reports whether any tracked file was modified since the last load.
+func (r *certificateReloader) changed() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, file := range r.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			// Files may be briefly missing while a secret is being rotated,
+			// the next tick will try again.
+			continue
+		}
+
+		if !info.ModTime().Equal(r.modTimes[file]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// reload reads the certificate, key and client CA files and atomically
+// replaces the ones currently served.
+func (r *certificateReloader) reload() error {
+	ectx := errors.Context(
+		errors.Field("cert_file", r.config.CertFile),
+		errors.Field("key_file", r.config.KeyFile),
+		errors.Field("client_ca_file", r.config.ClientCAFile),
+	)
+
+	modTimes := map[string]time.Time{}
+	for _, file := range r.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			return errors.PropagateAs(errors.KindSystemError, err, "failed to stat TLS file", ectx)
+		}
+		modTimes[file] = info.ModTime()
+	}
+
+	certificate, err := tls.LoadX509KeyPair(r.config.CertFile, r.config.KeyFile)
+	if err != nil {
+		return errors.PropagateAs(errors.KindSystemError, err, "failed to load TLS key pair", ectx)
+	}
+
+	var clientCAs *x509.CertPool
+	if r.config.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.config.ClientCAFile)
+		if err != nil {
+			return errors.PropagateAs(errors.KindSystemError, err, "failed to read TLS client CA file", ectx)
+		}
+
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return errors.New(errors.KindSystemError, "no valid certificates found in TLS client CA file", ectx)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.certificate = &certificate
+	r.clientCAs = clientCAs
+	r.modTimes = modTimes
+	return nil
+}
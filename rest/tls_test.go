@@ -0,0 +1,218 @@
+//go:build unit
+// +build unit
+
+package rest
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// issue creates a certificate with the serial, signed by the parent or
+// self-signed without one.
+func issue(t *testing.T, serial int64, parent *testCert, isCA bool) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: "garlic"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCert{cert: cert, key: key}
+}
+
+func (c *testCert) pem(t *testing.T) ([]byte, []byte) {
+	der, err := x509.MarshalECPrivateKey(c.key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+}
+
+// write writes the certificate and key files, moving their modification
+// time forward so the reloader notices them on any filesystem.
+func (c *testCert) write(t *testing.T, certFile, keyFile string, modTime time.Time) {
+	certPEM, keyPEM := c.pem(t)
+	require.NoError(t, os.WriteFile(certFile, certPEM, 0o600))
+	require.NoError(t, os.WriteFile(keyFile, keyPEM, 0o600))
+	require.NoError(t, os.Chtimes(certFile, modTime, modTime))
+	require.NoError(t, os.Chtimes(keyFile, modTime, modTime))
+}
+
+// serveTLS serves the reloader certificates on a local port, returning its
+// address.
+func serveTLS(t *testing.T, r *certificateReloader) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})}
+	go func() { _ = srv.Serve(tls.NewListener(ln, r.TLSConfig())) }()
+	t.Cleanup(func() { _ = srv.Close() })
+
+	return ln.Addr().String()
+}
+
+func servedSerial(t *testing.T, addr string) int64 {
+	conn, err := tls.Dial("tcp", addr, &tls.Config{InsecureSkipVerify: true})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	return conn.ConnectionState().PeerCertificates[0].SerialNumber.Int64()
+}
+
+func TestCertificateReload(t *testing.T) {
+	dir := t.TempDir()
+	config := TLSConfigDefaults()
+	config.Enabled = true
+	config.CertFile = filepath.Join(dir, "tls.crt")
+	config.KeyFile = filepath.Join(dir, "tls.key")
+	config.ReloadInterval = 10 * time.Millisecond
+
+	issue(t, 1, nil, false).write(t, config.CertFile, config.KeyFile, time.Now())
+
+	reloader, err := newCertificateReloader(config)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go reloader.Watch(ctx, zap.NewNop())
+
+	addr := serveTLS(t, reloader)
+	assert.Equal(t, int64(1), servedSerial(t, addr))
+
+	issue(t, 2, nil, false).write(t, config.CertFile, config.KeyFile, time.Now().Add(time.Minute))
+	assert.Eventually(t, func() bool {
+		return servedSerial(t, addr) == 2
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := issue(t, 1, nil, true)
+	caPEM, _ := ca.pem(t)
+
+	config := TLSConfigDefaults()
+	config.Enabled = true
+	config.CertFile = filepath.Join(dir, "tls.crt")
+	config.KeyFile = filepath.Join(dir, "tls.key")
+	config.ClientCAFile = filepath.Join(dir, "ca.crt")
+	config.ClientAuth = ClientAuthRequireAndVerify
+	require.NoError(t, os.WriteFile(config.ClientCAFile, caPEM, 0o600))
+	issue(t, 2, ca, false).write(t, config.CertFile, config.KeyFile, time.Now())
+
+	reloader, err := newCertificateReloader(config)
+	require.NoError(t, err)
+	addr := serveTLS(t, reloader)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	get := func(certificates ...tls.Certificate) error {
+		client := &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: certificates},
+		}}
+
+		resp, err := client.Get("https://" + addr)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+
+	assert.Error(t, get())
+
+	client := issue(t, 3, ca, false)
+	assert.NoError(t, get(tls.Certificate{
+		Certificate: [][]byte{client.cert.Raw},
+		PrivateKey:  client.key,
+	}))
+
+	stranger := issue(t, 4, nil, false)
+	assert.Error(t, get(tls.Certificate{
+		Certificate: [][]byte{stranger.cert.Raw},
+		PrivateKey:  stranger.key,
+	}))
+}
+
+func TestTLSConfigValidate(t *testing.T) {
+	config := TLSConfigDefaults()
+	assert.NoError(t, config.Validate())
+
+	config.ClientAuth = ClientAuthRequireAndVerify
+	assert.ErrorIs(t, config.Validate(), ErrConfigMissingClientCA)
+
+	config.ClientCAFile = "ca.crt"
+	assert.NoError(t, config.Validate())
+
+	config.ClientAuth = "always"
+	assert.ErrorIs(t, config.Validate(), ErrConfigInvalidClientAuth)
+}
+
+func TestServerConfigure(t *testing.T) {
+	server := NewServer("test").Configure(nil)
+	assert.Equal(t, Defaults(), server.config)
+	assert.Equal(t, DefaultShutdownTimeout, server.ShutdownTimeout)
+
+	config := Defaults()
+	config.ShutdownTimeout = time.Second
+	assert.Equal(t, time.Second, server.Configure(config).ShutdownTimeout)
+}
+
+func TestServerShutdownTimeoutDefault(t *testing.T) {
+	server := NewServer("test").Configure(&Config{})
+
+	var remaining time.Duration
+	server.BeforeShutdown(func(ctx context.Context) error {
+		deadline, _ := ctx.Deadline()
+		remaining = time.Until(deadline)
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := server.Listen(ctx, "127.0.0.1:0")
+	cancel()
+
+	assert.ErrorIs(t, <-errCh, context.Canceled)
+	assert.Greater(t, remaining, DefaultShutdownTimeout-time.Second)
+}
@@ -0,0 +1,89 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+
+	"github.com/dexlabsio/garlic/errors"
+	"github.com/dexlabsio/garlic/request"
+)
+
+// NoContent is the output of typed handlers that answer without a body.
+// Such handlers respond with 204 No Content.
+type NoContent struct{}
+
+// TypedFunc is a handler that receives its input already decoded and
+// validated, and returns the output to be encoded as the response.
+type TypedFunc[In, Out any] func(ctx context.Context, in In) (Out, error)
+
+// GetJSON creates a GET route answering 200 with the handler output.
+func GetJSON[In, Out any](url string, f TypedFunc[In, Out]) *Route {
+	return Typed(http.MethodGet, url, http.StatusOK, f)
+}
+
+// PostJSON creates a POST route answering 201 with the handler output.
+func PostJSON[In, Out any](url string, f TypedFunc[In, Out]) *Route {
+	return Typed(http.MethodPost, url, http.StatusCreated, f)
+}
+
+// PutJSON creates a PUT route answering 200 with the handler output.
+func PutJSON[In, Out any](url string, f TypedFunc[In, Out]) *Route {
+	return Typed(http.MethodPut, url, http.StatusOK, f)
+}
+
+// PatchJSON creates a PATCH route answering 200 with the handler output.
+func PatchJSON[In, Out any](url string, f TypedFunc[In, Out]) *Route {
+	return Typed(http.MethodPatch, url, http.StatusOK, f)
+}
+
+// DeleteJSON creates a DELETE route answering 200 with the handler output,
+// or 204 when the output is NoContent.
+func DeleteJSON[In, Out any](url string, f TypedFunc[In, Out]) *Route {
+	return Typed(http.MethodDelete, url, http.StatusOK, f)
+}
+
+// Typed creates a route that decodes the request path, query and body into
+// In using request.Decode, calls the handler and encodes its output with the
+// given status code. Errors flow through the same path as any other route,
+// being logged and written by WriteError. It panics when In is not a struct
+// request.Decode can decode into, so such routes fail at registration.
+func Typed[In, Out any](method, url string, statusCode int, f TypedFunc[In, Out]) *Route {
+	if err := request.CheckDecodable(reflect.TypeFor[In]()); err != nil {
+		panic(errors.Propagate(err, "invalid input type of typed route", errors.Context(
+			errors.Field("method", method),
+			errors.Field("url", url),
+		)))
+	}
+
+	fn := func(w http.ResponseWriter, r *http.Request) error {
+		var in In
+		if err := request.Decode(r, &in); err != nil {
+			return err
+		}
+
+		// Errors are returned as they are so the response carries the
+		// message of the handler rather than a generic wrapper.
+		out, err := f(r.Context(), in)
+		if err != nil {
+			return err
+		}
+
+		if _, ok := any(out).(NoContent); ok {
+			w.WriteHeader(http.StatusNoContent)
+			return nil
+		}
+
+		WriteResponse(statusCode, out).Must(w)
+		return nil
+	}
+
+	return &Route{
+		Method:       method,
+		Pattern:      url,
+		Fn:           fn,
+		RequestType:  reflect.TypeFor[In](),
+		ResponseType: reflect.TypeFor[Out](),
+		StatusCode:   statusCode,
+	}
+}
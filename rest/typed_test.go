@@ -0,0 +1,146 @@
+//go:build unit
+// +build unit
+
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dexlabsio/garlic/errors"
+	"github.com/dexlabsio/garlic/test"
+	"github.com/stretchr/testify/assert"
+)
+
+type greetInput struct {
+	Id       int    `path:"id"`
+	Language string `query:"lang"`
+	Name     string `json:"name" validate:"required"`
+}
+
+type greetOutput struct {
+	Id      int    `json:"id"`
+	Message string `json:"message"`
+}
+
+func greet(ctx context.Context, in greetInput) (greetOutput, error) {
+	if in.Language == "xx" {
+		return greetOutput{}, errors.New(errors.KindNotFoundError, "language not found")
+	}
+
+	return greetOutput{Id: in.Id, Message: in.Language + ":" + in.Name}, nil
+}
+
+func TestTypedRoutes(t *testing.T) {
+	route := PostJSON("/greet/{id}", greet)
+
+	test.New(t, "should decode path, query and body into the input").
+		Handler(route.Handler()).
+		Post("/greet/42").
+		Param("id", "42").
+		Query("lang", "en").
+		Body(map[string]string{"name": "garlic"}).
+		ExpectStatus(http.StatusCreated).
+		ExpectResponse(`{"id": 42, "message": "en:garlic"}`).
+		End()
+
+	test.New(t, "should reject malformed path params").
+		Handler(route.Handler()).
+		Post("/greet/abc").
+		Param("id", "abc").
+		Body(map[string]string{"name": "garlic"}).
+		ExpectStatus(http.StatusBadRequest).
+		ExpectResponse(`{
+			"name": "InvalidRequestError::UserError::Error",
+			"error": "failed to decode request params",
+			"kind": "E00002",
+			"details": {"hint": "Something is wrong with the request field 'id'"}
+		}`).
+		End()
+
+	test.New(t, "should reject invalid inputs").
+		Handler(route.Handler()).
+		Post("/greet/42").
+		Param("id", "42").
+		Body(map[string]string{}).
+		ExpectStatus(http.StatusBadRequest).
+		ExpectResponse(`{
+			"name": "ValidationError::InvalidRequestError::UserError::Error",
+			"error": "failed to validate request",
+			"kind": "E00004",
+			"details": {
+				"hint": "please, verify the correctness of the fields",
+				"validation": {"name": "name is a required field"}
+			}
+		}`).
+		End()
+
+	test.New(t, "should write handler errors with their kind").
+		Handler(route.Handler()).
+		Post("/greet/42").
+		Param("id", "42").
+		Query("lang", "xx").
+		Body(map[string]string{"name": "garlic"}).
+		ExpectStatus(http.StatusNotFound).
+		ExpectResponse(`{
+			"name": "NotFoundError::UserError::Error",
+			"error": "language not found",
+			"kind": "E00003"
+		}`).
+		End()
+}
+
+func TestTypedNoContent(t *testing.T) {
+	route := DeleteJSON("/greet/{id}", func(ctx context.Context, in struct{}) (NoContent, error) {
+		return NoContent{}, nil
+	})
+
+	rec := httptest.NewRecorder()
+	route.Handler()(rec, httptest.NewRequest(http.MethodDelete, "/greet/42", nil))
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Empty(t, rec.Body.Bytes())
+}
+
+func TestTypedPathUnescape(t *testing.T) {
+	type fileInput struct {
+		Name string `path:"name"`
+	}
+
+	route := GetJSON("/files/{name}", func(ctx context.Context, in fileInput) (map[string]string, error) {
+		return map[string]string{"name": in.Name}, nil
+	})
+
+	test.New(t, "should unescape path params").
+		Handler(route.Handler()).
+		Get("/files/%s", "docs%2Freadme.md").
+		Param("name", "docs%2Freadme.md").
+		ExpectStatus(http.StatusOK).
+		ExpectResponse(`{"name": "docs/readme.md"}`).
+		End()
+}
+
+func TestTypedRejectsUndecodableInputs(t *testing.T) {
+	type mapInput struct {
+		Labels map[string]string `query:"labels"`
+	}
+
+	assert.Panics(t, func() {
+		GetJSON("/", func(ctx context.Context, in mapInput) (NoContent, error) { return NoContent{}, nil })
+	})
+
+	assert.Panics(t, func() {
+		GetJSON("/", func(ctx context.Context, in *greetInput) (NoContent, error) { return NoContent{}, nil })
+	})
+
+	assert.NotPanics(t, func() {
+		GetJSON("/", func(ctx context.Context, in struct {
+			Ids  []int   `query:"id"`
+			Page *uint16 `query:"page"`
+		}) (NoContent, error) {
+			return NoContent{}, nil
+		})
+	})
+}
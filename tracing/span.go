@@ -0,0 +1,44 @@
+package tracing
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Tracer starts the spans of traced operations. garlic emits no span until
+// a tracer is set, which lets services plug in the tracing library they
+// already use, such as OpenTelemetry, through a small adapter.
+type Tracer interface {
+	Start(ctx context.Context, name string, attributes map[string]string) (context.Context, Span)
+}
+
+// Span is an operation being traced, ended with the error it failed with,
+// if any.
+type Span interface {
+	End(err error)
+}
+
+var tracer atomic.Pointer[Tracer]
+
+// SetTracer enables tracing through the given tracer, or disables it when
+// the tracer is nil.
+func SetTracer(t Tracer) {
+	tracer.Store(&t)
+}
+
+// StartSpan starts a span of the operation when tracing is enabled. The
+// returned context carries the span, so nested operations are children of
+// it. Without a tracer, the context is returned as is with a span that
+// does nothing.
+func StartSpan(ctx context.Context, name string, attributes map[string]string) (context.Context, Span) {
+	t := tracer.Load()
+	if t == nil || *t == nil {
+		return ctx, nopSpan{}
+	}
+
+	return (*t).Start(ctx, name, attributes)
+}
+
+type nopSpan struct{}
+
+func (nopSpan) End(error) {}
@@ -1,10 +1,14 @@
 package worker
 
-import "sync"
+import (
+	"sync"
+	"sync/atomic"
+)
 
 // Pool is a pool of goroutines which can run tasks concurrently
 type Pool struct {
 	size      int
+	busy      atomic.Int64
 	waitGroup sync.WaitGroup
 	tasks     chan Task
 }
@@ -24,7 +28,9 @@ func (p *Pool) spawn() {
 	for i := 0; i < p.size; i++ {
 		go func() {
 			for task := range p.tasks {
+				p.busy.Add(1)
 				task()
+				p.busy.Add(-1)
 				p.waitGroup.Done()
 			}
 		}()
@@ -41,3 +47,22 @@ func (p *Pool) Submit(task Task) {
 func (p *Pool) WaitAll() {
 	p.waitGroup.Wait()
 }
+
+// Size returns the number of workers in the pool
+func (p *Pool) Size() int {
+	return p.size
+}
+
+// Busy returns the number of workers currently running a task
+func (p *Pool) Busy() int {
+	return int(p.busy.Load())
+}
+
+// Saturation returns the ratio of busy workers, from 0 (idle) to 1 (full)
+func (p *Pool) Saturation() float64 {
+	if p.size == 0 {
+		return 1
+	}
+
+	return float64(p.Busy()) / float64(p.size)
+}